@@ -0,0 +1,301 @@
+package analytics
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"feedback-io.backend/models"
+	"gorm.io/gorm"
+)
+
+// Intervals of a time series.
+const (
+	Day  = "day"
+	Week = "week"
+)
+
+// Intervals lists the accepted time series intervals.
+var Intervals = []string{Day, Week}
+
+// Breakdowns of a time series.
+const (
+	ByCategory = "category"
+	ByStatus   = "status"
+)
+
+// GroupBys lists the accepted time series breakdowns.
+var GroupBys = []string{ByCategory, ByStatus}
+
+// DateLayout is the format of dates in filters and periods.
+const DateLayout = "2006-01-02"
+
+// Filter selects the activity to count. From and To are days, both included; activity
+// on votes and comments is attributed to the category and status of their suggestion.
+type Filter struct {
+	From       time.Time
+	To         time.Time
+	CategoryId uint
+}
+
+// Counts is the activity in one bucket.
+type Counts struct {
+	Suggestions int64 `json:"suggestions"`
+	Votes       int64 `json:"votes"`
+	Upvotes     int64 `json:"upvotes"`
+	Downvotes   int64 `json:"downvotes"`
+	Comments    int64 `json:"comments"`
+}
+
+func (c *Counts) add(other Counts) {
+	c.Suggestions += other.Suggestions
+	c.Votes += other.Votes
+	c.Upvotes += other.Upvotes
+	c.Downvotes += other.Downvotes
+	c.Comments += other.Comments
+}
+
+type CategoryCounts struct {
+	CategoryId uint   `json:"category_id"`
+	Name       string `json:"name"`
+	Counts
+}
+
+type StatusCounts struct {
+	Status string `json:"status"`
+	Counts
+}
+
+// Summary is the activity of a date range: totals and the breakdowns by category and by
+// the current status of the suggestions.
+type Summary struct {
+	From       string           `json:"from"`
+	To         string           `json:"to"`
+	Totals     Counts           `json:"totals"`
+	ByCategory []CategoryCounts `json:"by_category"`
+	ByStatus   []StatusCounts   `json:"by_status"`
+}
+
+// Point is the activity of one period, and of one category or status when the series
+// is broken down.
+type Point struct {
+	Period     string `json:"period"`
+	CategoryId *uint  `json:"category_id,omitempty"`
+	Status     string `json:"status,omitempty"`
+	Counts
+}
+
+// Series is the activity of a date range per day or week (starting on Monday). Without a
+// breakdown every period is present, with zeros when nothing happened.
+type Series struct {
+	From     string  `json:"from"`
+	To       string  `json:"to"`
+	Interval string  `json:"interval"`
+	GroupBy  string  `json:"group_by,omitempty"`
+	Points   []Point `json:"points"`
+}
+
+// row is the activity of one day in one category and status.
+type row struct {
+	Day        string
+	CategoryId uint
+	Status     string
+	Counts
+}
+
+// load counts the activity per day, category and status with one GROUP BY query per
+// table; weeks and breakdowns are folded from the result, which stays small.
+func load(ctx context.Context, db *gorm.DB, filter Filter) ([]row, error) {
+	db = db.WithContext(ctx)
+	from := models.DateTime{Time: filter.From}
+	until := models.DateTime{Time: filter.To.AddDate(0, 0, 1)}
+
+	scope := func(table string) func(*gorm.DB) *gorm.DB {
+		return func(query *gorm.DB) *gorm.DB {
+			query = query.Where(table+".created_at >= ? AND "+table+".created_at < ?", from, until).
+				Where("suggestions.deleted_at IS NULL")
+			if filter.CategoryId != 0 {
+				query = query.Where("suggestions.category_id = ?", filter.CategoryId)
+			}
+			return query.Group("day, suggestions.category_id, suggestions.status")
+		}
+	}
+
+	var suggestions, votes, comments []row
+	if err := db.Table("suggestions").
+		Select("DATE(suggestions.created_at) AS day, suggestions.category_id, suggestions.status, COUNT(*) AS suggestions").
+		Scopes(scope("suggestions")).
+		Scan(&suggestions).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Table("votes").
+		Select("DATE(votes.created_at) AS day, suggestions.category_id, suggestions.status, COUNT(*) AS votes, " +
+			"SUM(CASE WHEN votes.value > 0 THEN 1 ELSE 0 END) AS upvotes, " +
+			"SUM(CASE WHEN votes.value < 0 THEN 1 ELSE 0 END) AS downvotes").
+		Joins("JOIN suggestions ON suggestions.id = votes.suggestion_id").
+		Scopes(scope("votes")).
+		Scan(&votes).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Table("comments").
+		Select("DATE(comments.created_at) AS day, suggestions.category_id, suggestions.status, COUNT(*) AS comments").
+		Joins("JOIN suggestions ON suggestions.id = comments.suggestion_id").
+		Where("comments.deleted_at IS NULL").
+		Scopes(scope("comments")).
+		Scan(&comments).Error; err != nil {
+		return nil, err
+	}
+
+	return slices.Concat(suggestions, votes, comments), nil
+}
+
+// Summarize counts the activity matching filter.
+func Summarize(ctx context.Context, db *gorm.DB, filter Filter) (Summary, error) {
+	summary := Summary{
+		From:       filter.From.Format(DateLayout),
+		To:         filter.To.Format(DateLayout),
+		ByCategory: []CategoryCounts{},
+		ByStatus:   []StatusCounts{},
+	}
+
+	rows, err := load(ctx, db, filter)
+	if err != nil {
+		return summary, err
+	}
+
+	categories := map[uint]*CategoryCounts{}
+	statuses := map[string]*StatusCounts{}
+	for _, r := range rows {
+		summary.Totals.add(r.Counts)
+
+		if categories[r.CategoryId] == nil {
+			categories[r.CategoryId] = &CategoryCounts{CategoryId: r.CategoryId}
+		}
+		categories[r.CategoryId].add(r.Counts)
+
+		if statuses[r.Status] == nil {
+			statuses[r.Status] = &StatusCounts{Status: r.Status}
+		}
+		statuses[r.Status].add(r.Counts)
+	}
+
+	names, err := categoryNames(ctx, db)
+	if err != nil {
+		return summary, err
+	}
+	for id, counts := range categories {
+		counts.Name = names[id]
+		summary.ByCategory = append(summary.ByCategory, *counts)
+	}
+	slices.SortFunc(summary.ByCategory, func(a, b CategoryCounts) int { return int(a.CategoryId) - int(b.CategoryId) })
+
+	// roadmap order, then statuses that are no longer valid
+	for _, status := range models.Statuses {
+		if counts, ok := statuses[status]; ok {
+			summary.ByStatus = append(summary.ByStatus, *counts)
+			delete(statuses, status)
+		}
+	}
+	for _, counts := range statuses {
+		summary.ByStatus = append(summary.ByStatus, *counts)
+	}
+	return summary, nil
+}
+
+// Timeseries counts the activity matching filter per interval, optionally broken down
+// by category or status (groupBy, empty for totals).
+func Timeseries(ctx context.Context, db *gorm.DB, filter Filter, interval, groupBy string) (Series, error) {
+	series := Series{
+		From:     filter.From.Format(DateLayout),
+		To:       filter.To.Format(DateLayout),
+		Interval: interval,
+		GroupBy:  groupBy,
+		Points:   []Point{},
+	}
+
+	rows, err := load(ctx, db, filter)
+	if err != nil {
+		return series, err
+	}
+
+	type key struct {
+		period   string
+		category uint
+		status   string
+	}
+	points := map[key]*Point{}
+	if groupBy == "" {
+		for period := periodStart(filter.From, interval); !period.After(filter.To); period = next(period, interval) {
+			p := period.Format(DateLayout)
+			points[key{period: p}] = &Point{Period: p}
+		}
+	}
+
+	for _, r := range rows {
+		day, err := time.ParseInLocation(DateLayout, r.Day[:min(len(r.Day), len(DateLayout))], time.Local)
+		if err != nil {
+			return series, err
+		}
+		k := key{period: periodStart(day, interval).Format(DateLayout)}
+		switch groupBy {
+		case ByCategory:
+			k.category = r.CategoryId
+		case ByStatus:
+			k.status = r.Status
+		}
+
+		point := points[k]
+		if point == nil {
+			point = &Point{Period: k.period, Status: k.status}
+			if groupBy == ByCategory {
+				point.CategoryId = &k.category
+			}
+			points[k] = point
+		}
+		point.add(r.Counts)
+	}
+
+	for _, point := range points {
+		series.Points = append(series.Points, *point)
+	}
+	slices.SortFunc(series.Points, func(a, b Point) int {
+		if c := strings.Compare(a.Period, b.Period); c != 0 {
+			return c
+		}
+		if a.CategoryId != nil && b.CategoryId != nil && *a.CategoryId != *b.CategoryId {
+			return int(*a.CategoryId) - int(*b.CategoryId)
+		}
+		return strings.Compare(a.Status, b.Status)
+	})
+	return series, nil
+}
+
+// periodStart is the day itself, or the Monday of its week.
+func periodStart(day time.Time, interval string) time.Time {
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	if interval == Week {
+		offset := (int(day.Weekday()) + 6) % 7
+		day = day.AddDate(0, 0, -offset)
+	}
+	return day
+}
+
+func next(period time.Time, interval string) time.Time {
+	if interval == Week {
+		return period.AddDate(0, 0, 7)
+	}
+	return period.AddDate(0, 0, 1)
+}
+
+func categoryNames(ctx context.Context, db *gorm.DB) (map[uint]string, error) {
+	var categories []models.Category
+	if err := db.WithContext(ctx).Select("id", "name").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(categories))
+	for _, category := range categories {
+		names[category.Id] = category.Name
+	}
+	return names, nil
+}
@@ -0,0 +1,184 @@
+package apierror
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Error codes shared with API clients.
+const (
+	CodeBadRequest      = "bad_request"
+	CodeValidation      = "validation_failed"
+	CodeUnauthorized    = "unauthorized"
+	CodeForbidden       = "forbidden"
+	CodeNotFound        = "not_found"
+	CodeConflict        = "conflict"
+	CodeIdempotencyKey  = "idempotency_key_reused"
+	CodeTooManyRequests = "too_many_requests"
+	CodeInternal        = "internal_error"
+	CodeUnavailable     = "service_unavailable"
+
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeNotAcceptable        = "not_acceptable"
+	CodeRequestTimeout       = "request_timeout"
+	CodePayloadTooLarge      = "payload_too_large"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeUpgradeRequired      = "upgrade_required"
+	CodeTimeout              = "gateway_timeout"
+	// CodeGeneric is the code of any other status.
+	CodeGeneric = "error"
+)
+
+// mysqlDuplicateEntry is the MySQL error number for unique constraint violations.
+const mysqlDuplicateEntry = 1062
+
+// Error is the error type returned by handlers. It is rendered by Handler as
+// {"success": false, "error": message, "code": code, "details": details}.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Details any
+
+	// cause is logged but never sent to the client
+	cause error
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.cause)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// WithDetails returns a copy of e carrying extra machine readable details.
+func (e *Error) WithDetails(details any) *Error {
+	clone := *e
+	clone.Details = details
+	return &clone
+}
+
+// Wrap returns a copy of e recording the underlying cause for logs.
+func (e *Error) Wrap(cause error) *Error {
+	clone := *e
+	clone.cause = cause
+	return &clone
+}
+
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func BadRequest(message string) *Error {
+	return New(fiber.StatusBadRequest, CodeBadRequest, message)
+}
+
+func Validation(message string, details any) *Error {
+	return New(fiber.StatusUnprocessableEntity, CodeValidation, message).WithDetails(details)
+}
+
+func Unauthorized(message string) *Error {
+	return New(fiber.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+func Forbidden(message string) *Error {
+	return New(fiber.StatusForbidden, CodeForbidden, message)
+}
+
+func NotFound(message string) *Error {
+	return New(fiber.StatusNotFound, CodeNotFound, message)
+}
+
+func Conflict(message string) *Error {
+	return New(fiber.StatusConflict, CodeConflict, message)
+}
+
+func TooManyRequests(message string) *Error {
+	return New(fiber.StatusTooManyRequests, CodeTooManyRequests, message)
+}
+
+func Internal(message string, cause error) *Error {
+	return New(fiber.StatusInternalServerError, CodeInternal, message).Wrap(cause)
+}
+
+// From converts any error into an *Error: API errors pass through, fiber errors keep
+// their status, missing records become 404 and unique violations become 409.
+func From(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return New(fiberErr.Code, codeForStatus(fiberErr.Code), fiberErr.Message)
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return NotFound("Resource not found").Wrap(err)
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.Is(err, gorm.ErrDuplicatedKey) || (errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry) {
+		return Conflict("Resource already exists").Wrap(err)
+	}
+
+	return Internal("Internal server error", err)
+}
+
+// FromDB maps database errors with a known meaning (missing record, duplicate key)
+// and reports anything else as an internal error described by message.
+func FromDB(err error, message string) *Error {
+	apiErr := From(err)
+	if apiErr.Status == fiber.StatusInternalServerError {
+		return Internal(message, err)
+	}
+	return apiErr
+}
+
+func codeForStatus(status int) string {
+	switch status {
+	case fiber.StatusBadRequest:
+		return CodeBadRequest
+	case fiber.StatusUnprocessableEntity:
+		return CodeValidation
+	case fiber.StatusUnauthorized:
+		return CodeUnauthorized
+	case fiber.StatusForbidden:
+		return CodeForbidden
+	case fiber.StatusNotFound:
+		return CodeNotFound
+	case fiber.StatusConflict:
+		return CodeConflict
+	case fiber.StatusTooManyRequests:
+		return CodeTooManyRequests
+	case fiber.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case fiber.StatusNotAcceptable:
+		return CodeNotAcceptable
+	case fiber.StatusRequestTimeout:
+		return CodeRequestTimeout
+	case fiber.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case fiber.StatusUnsupportedMediaType:
+		return CodeUnsupportedMediaType
+	case fiber.StatusUpgradeRequired:
+		return CodeUpgradeRequired
+	case fiber.StatusServiceUnavailable:
+		return CodeUnavailable
+	case fiber.StatusGatewayTimeout:
+		return CodeTimeout
+	}
+	if status >= fiber.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeGeneric
+}
@@ -0,0 +1,62 @@
+package apierror
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"feedback-io.backend/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+)
+
+// Handler is the fiber.Config ErrorHandler. It renders every error returned by a
+// handler or middleware in the standard envelope and logs server side failures.
+func Handler(c *fiber.Ctx, err error) error {
+	apiErr := From(err)
+
+	if apiErr.Status >= fiber.StatusInternalServerError {
+		logger.FromContext(c.UserContext()).Error("request failed",
+			"method", c.Method(),
+			"path", c.Path(),
+			"status", apiErr.Status,
+			"error", err.Error(),
+		)
+	}
+
+	body := fiber.Map{
+		"success": false,
+		"error":   apiErr.Message,
+		"code":    apiErr.Code,
+	}
+	if apiErr.Details != nil {
+		body["details"] = apiErr.Details
+	}
+	return c.Status(apiErr.Status).JSON(body)
+}
+
+// Middleware recovers panics and renders errors returned by the routes behind it right away,
+// so outer middleware (access log, metrics, tracing) observe the final status code.
+// Register it last, just before the routes.
+func Middleware() fiber.Handler {
+	recovery := Recover()
+	return func(c *fiber.Ctx) error {
+		if err := recovery(c); err != nil {
+			return Handler(c, err)
+		}
+		return nil
+	}
+}
+
+// Recover turns panics into internal errors so they are rendered by Handler
+// in the same envelope as any other failure.
+func Recover() fiber.Handler {
+	return recover.New(recover.Config{
+		EnableStackTrace: true,
+		StackTraceHandler: func(c *fiber.Ctx, e interface{}) {
+			logger.FromContext(c.UserContext()).Error("panic recovered",
+				"panic", fmt.Sprint(e),
+				"stack", string(debug.Stack()),
+			)
+		},
+	})
+}
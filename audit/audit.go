@@ -0,0 +1,111 @@
+// Package audit records administrative and destructive actions in the audit_events table.
+// Every such action goes through Record, preferably within the transaction of the change,
+// so the event is stored if and only if the change is.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"feedback-io.backend/auth"
+	"feedback-io.backend/logger"
+	"feedback-io.backend/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"gorm.io/gorm"
+)
+
+// Actions.
+const (
+	SuggestionDelete       = "suggestion.delete"
+	SuggestionStatusChange = "suggestion.status_change"
+	WebhookCreate          = "webhook.create"
+	WebhookUpdate          = "webhook.update"
+	WebhookDelete          = "webhook.delete"
+	WebhookRedeliver       = "webhook.redeliver"
+	JobRetry               = "job.retry"
+	TaskRun                = "task.run"
+	ImportRun              = "import.run"
+	ContentPurge           = "content.purge"
+	ModerationHide         = "moderation.hide"
+	ModerationUnhide       = "moderation.unhide"
+	ModerationDelete       = "moderation.delete"
+	ModerationDismiss      = "moderation.dismiss"
+	ModerationAutoHide     = "moderation.auto_hide"
+	ModerationAutoHold     = "moderation.auto_hold"
+)
+
+// Target types.
+const (
+	TargetSuggestion = "suggestion"
+	TargetComment    = "comment"
+	TargetReply      = "reply"
+	TargetWebhook    = "webhook"
+	TargetJob        = "job"
+	TargetTask       = "task"
+	TargetImport     = "import"
+)
+
+// Actor is who performed an action, and from where.
+type Actor struct {
+	UserId    uint
+	Ip        string
+	RequestId string
+}
+
+// System is the actor of actions without a request, like the import command.
+var System = Actor{}
+
+// ActorOf returns the caller of the request.
+func ActorOf(c *fiber.Ctx) Actor {
+	id, _ := auth.UserID(c)
+	return Actor{
+		UserId:    id,
+		Ip:        c.IP(),
+		RequestId: utils.CopyString(logger.RequestID(c.UserContext())),
+	}
+}
+
+// Event is one action on a target. Before and After are the relevant state of the target,
+// stored as JSON; nil when there is none, e.g. no After for a deletion.
+type Event struct {
+	Action     string
+	TargetType string
+	TargetId   any
+	Before     any
+	After      any
+}
+
+// Record appends event, performed by actor, to the audit log.
+func Record(db *gorm.DB, actor Actor, event Event) error {
+	row := models.AuditEvent{
+		ActorId:    actor.UserId,
+		Action:     event.Action,
+		TargetType: event.TargetType,
+		Ip:         actor.Ip,
+		RequestId:  actor.RequestId,
+	}
+	if event.TargetId != nil {
+		row.TargetId = fmt.Sprint(event.TargetId)
+	}
+
+	var err error
+	if row.Before, err = marshal(event.Before); err != nil {
+		return err
+	}
+	if row.After, err = marshal(event.After); err != nil {
+		return err
+	}
+	return db.Create(&row).Error
+}
+
+func marshal(state any) (json.RawMessage, error) {
+	if state == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("audit: encoding state: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Roles granted through the access token.
+const (
+	RoleAdmin     = "admin"
+	RoleModerator = "moderator"
+	// RoleAnalyst can read the analytics endpoints.
+	RoleAnalyst = "analyst"
+)
+
+const (
+	localsUserID    = "user_id"
+	localsUserRoles = "user_roles"
+)
+
+// Identify resolves the caller from the access token in the Authorization header (see
+// IssueToken). Anonymous requests are let through; an invalid or expired token is rejected.
+func Identify() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		value := c.Get(fiber.HeaderAuthorization)
+		if value == "" {
+			return c.Next()
+		}
+
+		token, ok := strings.CutPrefix(value, "Bearer ")
+		if !ok {
+			return fiber.NewError(fiber.StatusUnauthorized, "Expected a Bearer token")
+		}
+		claims, err := VerifyToken(strings.TrimSpace(token))
+		if err != nil {
+			return fiber.NewError(fiber.StatusUnauthorized, "Invalid or expired token")
+		}
+
+		c.Locals(localsUserID, claims.UserId)
+		c.Locals(localsUserRoles, claims.Roles)
+
+		return c.Next()
+	}
+}
+
+// UserID returns the authenticated user id, if the request carried one.
+func UserID(c *fiber.Ctx) (uint, bool) {
+	id, ok := c.Locals(localsUserID).(uint)
+	return id, ok
+}
+
+// HasRole reports whether the authenticated user was granted role.
+func HasRole(c *fiber.Ctx, role string) bool {
+	roles, _ := c.Locals(localsUserRoles).([]string)
+	return slices.Contains(roles, role)
+}
+
+// Required rejects anonymous requests with 401.
+func Required() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if _, ok := UserID(c); !ok {
+			return fiber.NewError(fiber.StatusUnauthorized, "Authentication required")
+		}
+		return c.Next()
+	}
+}
+
+// RequireRole rejects anonymous requests with 401 and users holding none of roles with 403.
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if _, ok := UserID(c); !ok {
+			return fiber.NewError(fiber.StatusUnauthorized, "Authentication required")
+		}
+		for _, role := range roles {
+			if HasRole(c, role) {
+				return c.Next()
+			}
+		}
+		return fiber.NewError(fiber.StatusForbidden, "Insufficient permissions")
+	}
+}
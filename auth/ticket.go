@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tickets are short lived, HMAC signed credentials that carry an authenticated user
+// to channels where the Authorization header can't be set, e.g. the WebSocket handshake.
+
+var ErrInvalidTicket = errors.New("invalid or expired ticket")
+
+var ticketKey = &signingKey{env: "WS_TICKET_SECRET", purpose: "ticket"}
+
+// IssueTicket returns a ticket for userID valid for ttl.
+func IssueTicket(userID uint, ttl time.Duration) string {
+	return ticketKey.seal(fmt.Sprintf("%d.%d", userID, time.Now().Add(ttl).Unix()))
+}
+
+// VerifyTicket returns the user id carried by a valid, unexpired ticket.
+func VerifyTicket(ticket string) (uint, error) {
+	payload, ok := ticketKey.open(ticket)
+	if !ok {
+		return 0, ErrInvalidTicket
+	}
+
+	userPart, expiryPart, ok := strings.Cut(payload, ".")
+	if !ok {
+		return 0, ErrInvalidTicket
+	}
+	userID, ok := parseClaims(userPart, expiryPart)
+	if !ok {
+		return 0, ErrInvalidTicket
+	}
+	return userID, nil
+}
+
+// parseClaims checks the expiry (unix seconds) and the user id shared by tickets and tokens.
+func parseClaims(userPart, expiryPart string) (uint, bool) {
+	expiry, err := strconv.ParseInt(expiryPart, 10, 64)
+	if err != nil || time.Now().Unix() > expiry {
+		return 0, false
+	}
+	userID, err := strconv.ParseUint(userPart, 10, 32)
+	if err != nil || userID == 0 {
+		return 0, false
+	}
+	return uint(userID), true
+}
+
+// signingKey signs the payload of one kind of credential with the secret read from env.
+// The purpose is part of the signature, so a credential of one kind is never accepted as
+// another even when both secrets are the same.
+type signingKey struct {
+	env     string
+	purpose string
+
+	once   sync.Once
+	secret []byte
+}
+
+// key reads the secret. Without it a random per-process secret is used, which only
+// works with a single replica.
+func (k *signingKey) key() []byte {
+	k.once.Do(func() {
+		if value := os.Getenv(k.env); value != "" {
+			k.secret = []byte(value)
+			return
+		}
+		log.Printf("%s is not set, using a random secret", k.env)
+		k.secret = make([]byte, 32)
+		if _, err := rand.Read(k.secret); err != nil {
+			panic(err)
+		}
+	})
+	return k.secret
+}
+
+// seal returns payload with its signature, as "base64(payload).signature".
+func (k *signingKey) seal(payload string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + k.sign(payload)
+}
+
+// open returns the payload of a credential made by seal if its signature is valid.
+func (k *signingKey) open(credential string) (string, bool) {
+	encoded, signature, ok := strings.Cut(credential, ".")
+	if !ok {
+		return "", false
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", false
+	}
+	payload := string(raw)
+	if !hmac.Equal([]byte(k.sign(payload)), []byte(signature)) {
+		return "", false
+	}
+	return payload, true
+}
+
+func (k *signingKey) sign(payload string) string {
+	mac := hmac.New(sha256.New, k.key())
+	mac.Write([]byte(k.purpose + "\n" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
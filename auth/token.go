@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Access tokens are HMAC signed credentials, sent as "Authorization: Bearer <token>", that
+// identify the caller of a request. They are issued with AUTH_TOKEN_SECRET by the service
+// that authenticates users, or by the "token" command, and verified by Identify.
+
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+var tokenKey = &signingKey{env: "AUTH_TOKEN_SECRET", purpose: "token"}
+
+// Claims are what an access token asserts about its bearer. Roles are the lower case
+// Role* names granted to the user.
+type Claims struct {
+	UserId uint
+	Roles  []string
+}
+
+// IssueToken returns an access token for claims valid for ttl.
+func IssueToken(claims Claims, ttl time.Duration) string {
+	roles := normalizeRoles(claims.Roles)
+	return tokenKey.seal(fmt.Sprintf("%d.%d.%s", claims.UserId, time.Now().Add(ttl).Unix(), strings.Join(roles, ",")))
+}
+
+// VerifyToken returns the claims of a valid, unexpired access token.
+func VerifyToken(token string) (Claims, error) {
+	payload, ok := tokenKey.open(token)
+	if !ok {
+		return Claims{}, ErrInvalidToken
+	}
+
+	parts := strings.SplitN(payload, ".", 3)
+	if len(parts) != 3 {
+		return Claims{}, ErrInvalidToken
+	}
+	userID, ok := parseClaims(parts[0], parts[1])
+	if !ok {
+		return Claims{}, ErrInvalidToken
+	}
+	return Claims{UserId: userID, Roles: normalizeRoles(strings.Split(parts[2], ","))}, nil
+}
+
+// normalizeRoles lower cases roles and drops empty ones and those that can't be encoded.
+func normalizeRoles(roles []string) []string {
+	var normalized []string
+	for _, role := range roles {
+		role = strings.ToLower(strings.TrimSpace(role))
+		if role != "" && !strings.ContainsAny(role, ".,") {
+			normalized = append(normalized, role)
+		}
+	}
+	return normalized
+}
@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"feedback-io.backend/config"
+)
+
+// SuggestionsPrefix namespaces every cached suggestion listing.
+const SuggestionsPrefix = "suggestions:"
+
+// Store is a byte cache. MemoryStore is the default; a shared implementation
+// (e.g. Redis) keeps replicas consistent.
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
+// Default is the cache used by the controllers. It is nil (caching disabled) until Setup runs.
+var Default Store
+
+// TTL bounds how long an entry may be served when an invalidation was missed.
+var TTL = 30 * time.Second
+
+// Setup enables the in-memory cache unless CACHE_ENABLED is false.
+func Setup() {
+	if !config.GetEnvBool("CACHE_ENABLED", true) {
+		log.Println("Response cache disabled")
+		return
+	}
+	TTL = config.GetEnvDuration("CACHE_TTL", TTL)
+	Default = NewMemoryStore()
+}
+
+// Get reads key from the default store. Errors are treated as misses.
+func Get(ctx context.Context, key string) ([]byte, bool) {
+	if Default == nil {
+		return nil, false
+	}
+	value, ok, err := Default.Get(ctx, key)
+	if err != nil {
+		return nil, false
+	}
+	return value, ok
+}
+
+// Set writes key to the default store with the default TTL.
+func Set(ctx context.Context, key string, value []byte) {
+	if Default == nil {
+		return
+	}
+	if err := Default.Set(ctx, key, value, TTL); err != nil {
+		log.Printf("Failed to write cache entry %s: %v", key, err)
+	}
+}
+
+// Invalidate drops every entry under prefix. Call it after the change is committed.
+func Invalidate(ctx context.Context, prefix string) {
+	if Default == nil {
+		return
+	}
+	if err := Default.DeletePrefix(ctx, prefix); err != nil {
+		log.Printf("Failed to invalidate cache prefix %s: %v", prefix, err)
+	}
+}
+
+type entry struct {
+	value   []byte
+	expires time.Time
+}
+
+// MemoryStore is a process local Store.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	entries map[string]entry
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[string]entry{}}
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	s.mu.RLock()
+	e, ok := s.entries[key]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, false, nil
+	}
+	if time.Now().After(e.expires) {
+		s.mu.Lock()
+		delete(s.entries, key)
+		s.mu.Unlock()
+		return nil, false, nil
+	}
+	return e.value, true, nil
+}
+
+func (s *MemoryStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = entry{value: value, expires: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) DeletePrefix(_ context.Context, prefix string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key := range s.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.entries, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ETag returns a weak entity tag for the given representation parts.
+func ETag(parts ...[]byte) string {
+	h := sha1.New()
+	for _, part := range parts {
+		h.Write(part)
+		h.Write([]byte{0})
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:12]) + `"`
+}
+
+// NotModified sets the ETag, Last-Modified and Cache-Control headers and reports whether
+// the client's copy is still fresh (If-None-Match, or If-Modified-Since when no ETag was sent).
+// When it returns true the handler should respond with 304 and no body.
+func NotModified(c *fiber.Ctx, etag string, lastModified time.Time) bool {
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	if !lastModified.IsZero() {
+		c.Set(fiber.HeaderLastModified, lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if match := c.Get(fiber.HeaderIfNoneMatch); match != "" {
+		return etagMatches(match, etag)
+	}
+
+	if since := c.Get(fiber.HeaderIfModifiedSince); since != "" && !lastModified.IsZero() {
+		if t, err := http.ParseTime(since); err == nil {
+			return !lastModified.Truncate(time.Second).After(t)
+		}
+	}
+	return false
+}
+
+// etagMatches uses weak comparison, as required for If-None-Match.
+func etagMatches(header, etag string) bool {
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestETag(t *testing.T) {
+	a := ETag([]byte("ab"), []byte("c"))
+	if a != ETag([]byte("ab"), []byte("c")) {
+		t.Error("ETag is not deterministic")
+	}
+	if a == ETag([]byte("a"), []byte("bc")) {
+		t.Error("ETag ignores part boundaries")
+	}
+	if len(a) < 4 || a[:3] != `W/"` {
+		t.Errorf("ETag %s is not weak", a)
+	}
+}
+
+func TestEtagMatches(t *testing.T) {
+	etag := `W/"abc"`
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{`W/"abc"`, true},
+		{`"abc"`, true},
+		{`*`, true},
+		{`"x", W/"abc"`, true},
+		{`"x",W/"y"`, false},
+		{`W/"abcd"`, false},
+		{`abc`, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, etag); got != tt.want {
+			t.Errorf("etagMatches(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	etag := `W/"abc"`
+	modified := time.Date(2026, 3, 1, 12, 0, 0, 500, time.UTC)
+
+	tests := []struct {
+		name         string
+		headers      map[string]string
+		lastModified time.Time
+		want         bool
+	}{
+		{"no validators", nil, modified, false},
+		{"matching etag", map[string]string{"If-None-Match": etag}, modified, true},
+		{"other etag", map[string]string{"If-None-Match": `W/"x"`}, modified, false},
+		{"etag wins over date", map[string]string{"If-None-Match": `W/"x"`, "If-Modified-Since": modified.Add(time.Hour).Format(http.TimeFormat)}, modified, false},
+		{"not modified since", map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, modified, true},
+		{"modified since", map[string]string{"If-Modified-Since": modified.Add(-time.Second).Format(http.TimeFormat)}, modified, false},
+		{"unparsable date", map[string]string{"If-Modified-Since": "yesterday"}, modified, false},
+		{"no last modified", map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/", func(c *fiber.Ctx) error {
+				if NotModified(c, etag, tt.lastModified) {
+					return c.SendStatus(fiber.StatusNotModified)
+				}
+				return c.SendString("body")
+			})
+
+			req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := resp.StatusCode == fiber.StatusNotModified; got != tt.want {
+				t.Errorf("not modified = %v, want %v", got, tt.want)
+			}
+			if resp.Header.Get(fiber.HeaderETag) != etag {
+				t.Errorf("ETag header = %q", resp.Header.Get(fiber.HeaderETag))
+			}
+			if got := resp.Header.Get(fiber.HeaderLastModified); (got != "") != !tt.lastModified.IsZero() {
+				t.Errorf("Last-Modified header = %q", got)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
@@ -20,22 +21,29 @@ func ConnectDatabase() {
 
 	db_conn, db_err := ConnectDB()
 	if db_err != nil {
-		log.Fatalf("Error connecting to database: %v", err)
+		log.Fatalf("Error connecting to database: %v", db_err)
 
 	}
 
-	// AutoMigrateDB(db_conn)
+	// `migrate` is the usual way to update the schema; DB_AUTO_MIGRATE does it on every start
+	if GetEnvBool("DB_AUTO_MIGRATE", false) {
+		AutoMigrateDB(db_conn)
+	}
 	// seeder.Seed(db_conn)
 
 	DB = db_conn
 
-	// defer func() {
-	// 	db, err := DB.DB()
-	// 	if err != nil {
-	// 		log.Fatalf("Failed to get database instance: %v", err)
+}
 
-	// 	}
-	// 	db.Close()
-	// }()
+// CloseDatabase closes the underlying sql.DB pool. It is safe to call when no connection was opened.
+func CloseDatabase() error {
+	if DB == nil {
+		return nil
+	}
 
+	db, err := DB.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database instance: %v", err)
+	}
+	return db.Close()
 }
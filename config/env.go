@@ -0,0 +1,86 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetEnvInt reads an integer environment variable, falling back to def when unset or invalid.
+func GetEnvInt(key string, def int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid value for %s (%q), using default %d", key, value, def)
+		return def
+	}
+	return parsed
+}
+
+// GetEnvDuration reads a duration environment variable (e.g. "30s", "5m"), falling back to def when unset or invalid.
+func GetEnvDuration(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid value for %s (%q), using default %s", key, value, def)
+		return def
+	}
+	return parsed
+}
+
+// GetEnvTime reads an RFC 3339 timestamp or a YYYY-MM-DD date. The zero time is returned when unset or invalid.
+func GetEnvTime(key string) time.Time {
+	value := os.Getenv(key)
+	if value == "" {
+		return time.Time{}
+	}
+
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed
+		}
+	}
+	log.Printf("Invalid value for %s (%q), ignoring", key, value)
+	return time.Time{}
+}
+
+// GetEnvBool reads a boolean environment variable ("true", "1", ...), falling back to def when unset or invalid.
+func GetEnvBool(key string, def bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Invalid value for %s (%q), using default %t", key, value, def)
+		return def
+	}
+	return parsed
+}
+
+// GetEnvList reads a comma separated environment variable, falling back to def when unset.
+func GetEnvList(key string, def []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	list := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
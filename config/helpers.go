@@ -4,8 +4,12 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
+	"feedback-io.backend/logger"
+	"feedback-io.backend/metrics"
 	"feedback-io.backend/models"
+	"feedback-io.backend/tracing"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
@@ -16,6 +20,10 @@ type DBConfig struct {
 	Name string
 	Host string
 	Port string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
 }
 
 func GetDBConfig() (*DBConfig, error) {
@@ -25,6 +33,10 @@ func GetDBConfig() (*DBConfig, error) {
 		Name: os.Getenv("MYSQL_DBNAME"),
 		Host: os.Getenv("MYSQL_DBHOST"),
 		Port: os.Getenv("MYSQL_DBPORT"),
+
+		MaxOpenConns:    GetEnvInt("MYSQL_MAX_OPEN_CONNS", 25),
+		MaxIdleConns:    GetEnvInt("MYSQL_MAX_IDLE_CONNS", 10),
+		ConnMaxLifetime: GetEnvDuration("MYSQL_CONN_MAX_LIFETIME", 5*time.Minute),
 	}
 
 	if config.Name == "" ||
@@ -45,27 +57,99 @@ func ConnectDB() (*gorm.DB, error) {
 	}
 
 	db_str := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s", db_config.User, db_config.Pass, db_config.Host, db_config.Port, db_config.Name)
-	db_conn, db_err := gorm.Open(mysql.Open(db_str), &gorm.Config{})
+	db_conn, db_err := gorm.Open(mysql.Open(db_str), &gorm.Config{
+		Logger:         logger.NewGormLogger(),
+		TranslateError: true,
+	})
 
 	if db_err != nil {
 		return nil, db_err
 	}
 
+	sql_db, pool_err := db_conn.DB()
+	if pool_err != nil {
+		return nil, pool_err
+	}
+	sql_db.SetMaxOpenConns(db_config.MaxOpenConns)
+	sql_db.SetMaxIdleConns(db_config.MaxIdleConns)
+	sql_db.SetConnMaxLifetime(db_config.ConnMaxLifetime)
+
+	if err := db_conn.Use(metrics.GormPlugin{}); err != nil {
+		return nil, err
+	}
+	metrics.RegisterDBStats(sql_db, db_config.Name)
+
+	if err := db_conn.Use(tracing.GormPlugin{}); err != nil {
+		return nil, err
+	}
+
 	log.Printf("Connected to [%s] at -> %s:%s", db_config.Name, db_config.Host, db_config.Port)
 	return db_conn, nil
 
 }
 
-func AutoMigrateDB(DB *gorm.DB) {
-	err := DB.Debug().AutoMigrate(
+// MigrationModels lists every model managed by AutoMigrateDB.
+func MigrationModels() []interface{} {
+	return []interface{}{
 		&models.Suggestion{},
 		&models.Comment{},
 		&models.Reply{},
 		&models.User{},
 		&models.Category{},
-	)
+		&models.Vote{},
+		&models.IdempotencyKey{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.Subscription{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.Job{},
+		&models.ScheduledTask{},
+		&models.AuditEvent{},
+		&models.Report{},
+	}
+}
+
+func AutoMigrateDB(DB *gorm.DB) {
+	err := MigrateDB(DB)
 	if err != nil {
 		log.Fatalf("Error occured migrating database: %v", err)
 	}
 
 }
+
+// MigrateDB creates the missing tables, columns and indexes of MigrationModels. It never
+// drops anything.
+func MigrateDB(DB *gorm.DB) error {
+	return DB.AutoMigrate(MigrationModels()...)
+}
+
+// PendingMigrations returns the tables and columns required by MigrationModels that are missing from the database.
+func PendingMigrations(DB *gorm.DB) ([]string, error) {
+	pending := []string{}
+	migrator := DB.Migrator()
+
+	for _, model := range MigrationModels() {
+		stmt := &gorm.Statement{DB: DB}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+
+		table := stmt.Schema.Table
+		if !migrator.HasTable(model) {
+			pending = append(pending, table)
+			continue
+		}
+
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			if !migrator.HasColumn(model, field.DBName) {
+				pending = append(pending, table+"."+field.DBName)
+			}
+		}
+	}
+
+	return pending, nil
+}
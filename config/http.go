@@ -0,0 +1,52 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+type HTTPConfig struct {
+	AllowedOrigins   []string
+	AllowCredentials bool
+	PreflightMaxAge  time.Duration
+
+	HSTSMaxAge                int
+	FrameOptions              string
+	ContentSecurityPolicy     string
+	DocsContentSecurityPolicy string
+
+	// BodyLimit is the maximum request body size in bytes.
+	BodyLimit int
+
+	// ProxyHeader holds the client address set by the reverse proxy, e.g. X-Real-IP. It is
+	// only read on requests coming from TrustedProxies (addresses or CIDR ranges).
+	ProxyHeader    string
+	TrustedProxies []string
+}
+
+func GetHTTPConfig() *HTTPConfig {
+	return &HTTPConfig{
+		AllowedOrigins:   GetEnvList("CORS_ALLOWED_ORIGINS", []string{"https://feedback-io.netlify.app"}),
+		AllowCredentials: GetEnvBool("CORS_ALLOW_CREDENTIALS", false),
+		PreflightMaxAge:  GetEnvDuration("CORS_MAX_AGE", 10*time.Minute),
+
+		HSTSMaxAge:   GetEnvInt("HSTS_MAX_AGE", 31536000),
+		FrameOptions: getEnvString("FRAME_OPTIONS", "DENY"),
+		ContentSecurityPolicy: getEnvString("CONTENT_SECURITY_POLICY",
+			"default-src 'none'; frame-ancestors 'none'"),
+		DocsContentSecurityPolicy: getEnvString("DOCS_CONTENT_SECURITY_POLICY",
+			"default-src 'self'; script-src 'self' 'unsafe-inline' https://unpkg.com; style-src 'self' https://unpkg.com; img-src 'self' data:; connect-src 'self'; frame-ancestors 'none'"),
+
+		BodyLimit: GetEnvInt("BODY_LIMIT_BYTES", 1024*1024),
+
+		ProxyHeader:    os.Getenv("PROXY_HEADER"),
+		TrustedProxies: GetEnvList("TRUSTED_PROXIES", nil),
+	}
+}
+
+func getEnvString(key, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
@@ -0,0 +1,163 @@
+package contentcheck
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"feedback-io.backend/models"
+	"feedback-io.backend/ratelimit"
+	"gorm.io/gorm"
+)
+
+// Repetition thresholds: a character repeated repeatedRunLength times in a row, or a text
+// of at least repeatedMinWords words of which fewer than repeatedDistinctRatio are
+// distinct.
+const (
+	repeatedRunLength     = 12
+	repeatedMinWords      = 12
+	repeatedDistinctRatio = 0.3
+)
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+
+// WordList flags content containing any of words, as whole words and ignoring case.
+// Entries may be phrases.
+func WordList(words []string) Check {
+	quoted := make([]string, 0, len(words))
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+	}
+	pattern := regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+
+	return Check{
+		Name: "word_list",
+		Run: func(ctx context.Context, content Content) (string, error) {
+			if match := pattern.FindString(content.Text()); match != "" {
+				return fmt.Sprintf("contains blocked word %q", strings.ToLower(match)), nil
+			}
+			return "", nil
+		},
+	}
+}
+
+// Links flags content with more than max links.
+func Links(max int) Check {
+	return Check{
+		Name: "links",
+		Run: func(ctx context.Context, content Content) (string, error) {
+			if n := len(linkPattern.FindAllStringIndex(content.Text(), -1)); n > max {
+				return fmt.Sprintf("%d links, at most %d allowed", n, max), nil
+			}
+			return "", nil
+		},
+	}
+}
+
+// Repetition flags long runs of one character ("!!!!!!!!!!!!") and texts that repeat the
+// same few words over and over.
+func Repetition() Check {
+	return Check{
+		Name: "repetition",
+		Run: func(ctx context.Context, content Content) (string, error) {
+			text := content.Text()
+
+			var previous rune
+			run := 0
+			for _, r := range text {
+				if r == previous && r != ' ' {
+					run++
+				} else {
+					previous, run = r, 1
+				}
+				if run >= repeatedRunLength {
+					return fmt.Sprintf("%q repeated %d times or more", string(r), repeatedRunLength), nil
+				}
+			}
+
+			words := strings.Fields(strings.ToLower(text))
+			if len(words) < repeatedMinWords {
+				return "", nil
+			}
+			distinct := map[string]struct{}{}
+			for _, word := range words {
+				distinct[word] = struct{}{}
+			}
+			if float64(len(distinct)) < float64(len(words))*repeatedDistinctRatio {
+				return fmt.Sprintf("%d distinct words out of %d", len(distinct), len(words)), nil
+			}
+			return "", nil
+		},
+	}
+}
+
+// Velocity flags content from a user who already posted limit suggestions, comments and
+// replies, together, within window. Deleted posts count too. Posts don't record an
+// address, so anonymous content (UserId 0) is counted per Ip in store instead.
+func Velocity(db *gorm.DB, store ratelimit.Store, limit int, window time.Duration) Check {
+	anonymous := ratelimit.Policy{Name: "content:velocity", Limit: limit, Window: window}
+	return Check{
+		Name: "velocity",
+		Run: func(ctx context.Context, content Content) (string, error) {
+			if content.UserId == 0 {
+				if content.Ip == "" {
+					return "", nil
+				}
+				result, err := store.Take(ctx, anonymous.Name+":ip:"+content.Ip, anonymous)
+				if err != nil {
+					return "", err
+				}
+				if !result.Allowed {
+					return fmt.Sprintf("more than %d anonymous posts from this address in the last %s", limit, window), nil
+				}
+				return "", nil
+			}
+			since := models.DateTime{Time: time.Now().Add(-window)}
+
+			var total int64
+			for _, table := range []string{"suggestions", "comments", "replies"} {
+				var n int64
+				if err := db.WithContext(ctx).Table(table).
+					Where("user_id = ? AND created_at >= ?", content.UserId, since).
+					Count(&n).Error; err != nil {
+					return "", err
+				}
+				total += n
+			}
+			if total >= int64(limit) {
+				return fmt.Sprintf("%d posts in the last %s", total, window), nil
+			}
+			return "", nil
+		},
+	}
+}
+
+// Classifier scores content, e.g. through an external spam or toxicity service. Score is
+// between 0 (clean) and 1 (certainly abusive).
+type Classifier interface {
+	Classify(ctx context.Context, content Content) (score float64, err error)
+}
+
+// Classify flags content that classifier scores at threshold or above. Plug it in at
+// startup, after Setup:
+//
+//	contentcheck.Default.Use(contentcheck.Classify("toxicity", client, 0.8))
+func Classify(name string, classifier Classifier, threshold float64) Check {
+	return Check{
+		Name: name,
+		Run: func(ctx context.Context, content Content) (string, error) {
+			score, err := classifier.Classify(ctx, content)
+			if err != nil {
+				return "", err
+			}
+			if score >= threshold {
+				return fmt.Sprintf("score %.2f, threshold %.2f", score, threshold), nil
+			}
+			return "", nil
+		},
+	}
+}
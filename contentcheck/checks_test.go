@@ -0,0 +1,138 @@
+package contentcheck
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"feedback-io.backend/ratelimit"
+)
+
+func TestWordList(t *testing.T) {
+	check := WordList([]string{"spam", " Buy Now ", ""})
+
+	tests := []struct {
+		name    string
+		content Content
+		flagged bool
+	}{
+		{"clean", Content{Body: "Please add dark mode"}, false},
+		{"whole word", Content{Body: "this is spam"}, true},
+		{"ignores case", Content{Body: "SPAM everywhere"}, true},
+		{"not inside a word", Content{Body: "spammer and spamming"}, false},
+		{"phrase", Content{Body: "buy now, limited offer"}, true},
+		{"phrase split by a newline", Content{Body: "buy\nnow"}, false},
+		{"checks the title", Content{Title: "Spam", Body: "fine"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, err := check.Run(context.Background(), tt.content)
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if got := reason != ""; got != tt.flagged {
+				t.Errorf("flagged = %v (reason %q), want %v", got, reason, tt.flagged)
+			}
+		})
+	}
+}
+
+func TestLinks(t *testing.T) {
+	tests := []struct {
+		name    string
+		max     int
+		body    string
+		flagged bool
+	}{
+		{"no links", 0, "no links here", false},
+		{"at the limit", 2, "see https://a.example and http://b.example", false},
+		{"over the limit", 1, "see https://a.example and www.b.example", true},
+		{"zero allows none", 0, "HTTPS://A.EXAMPLE", true},
+		{"bare domain is not a link", 0, "mail me at example.com", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, err := Links(tt.max).Run(context.Background(), Content{Body: tt.body})
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if got := reason != ""; got != tt.flagged {
+				t.Errorf("flagged = %v (reason %q), want %v", got, reason, tt.flagged)
+			}
+		})
+	}
+}
+
+func TestRepetition(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		flagged bool
+	}{
+		{"ordinary text", "The export button should also offer CSV next to the PDF option", false},
+		{"short run", "great!!!!!!!!!!!", false},
+		{"long run", "great!!!!!!!!!!!!", true},
+		{"spaces don't count as a run", "a" + strings.Repeat(" ", 20) + "b", false},
+		{"few words repeated", strings.Repeat("buy this now ", 6), true},
+		{"too short to judge", strings.Repeat("yes ", 11), false},
+		{"repeated words ignore case", strings.Repeat("Yes yes YES ", 4), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, err := Repetition().Run(context.Background(), Content{Body: tt.body})
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if got := reason != ""; got != tt.flagged {
+				t.Errorf("flagged = %v (reason %q), want %v", got, reason, tt.flagged)
+			}
+		})
+	}
+}
+
+func TestVelocityAnonymous(t *testing.T) {
+	// anonymous content is counted in the store only, so no database is needed
+	check := Velocity(nil, ratelimit.NewMemoryStore(), 2, time.Minute)
+
+	steps := []struct {
+		content Content
+		flagged bool
+	}{
+		{Content{Ip: "192.0.2.1"}, false},
+		{Content{Ip: "192.0.2.1"}, false},
+		{Content{Ip: "192.0.2.1"}, true},
+		{Content{Ip: "192.0.2.2"}, false},
+		{Content{}, false},
+	}
+	for i, step := range steps {
+		reason, err := check.Run(context.Background(), step.content)
+		if err != nil {
+			t.Fatalf("step %d: Run: %v", i, err)
+		}
+		if got := reason != ""; got != step.flagged {
+			t.Errorf("step %d: flagged = %v (reason %q), want %v", i, got, reason, step.flagged)
+		}
+	}
+}
+
+func TestPipelineTimeout(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	p := &Pipeline{Timeout: 10 * time.Millisecond}
+	p.Use(
+		Check{Name: "stuck", Run: func(ctx context.Context, content Content) (string, error) {
+			<-block
+			return "too late", nil
+		}},
+		Check{Name: "quick", Run: func(ctx context.Context, content Content) (string, error) {
+			return "flagged", nil
+		}},
+	)
+
+	verdict := p.Run(context.Background(), Content{Body: "x"})
+	if !verdict.Flagged || len(verdict.Reasons) != 1 || verdict.Reasons[0] != "quick: flagged" {
+		t.Errorf("verdict = %+v, want only the quick check", verdict)
+	}
+}
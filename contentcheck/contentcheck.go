@@ -0,0 +1,165 @@
+// Package contentcheck screens user content (suggestions, comments and replies) before it
+// is stored. A Pipeline runs a list of checks; content any check flags is still stored but
+// hidden and queued for the moderators (moderation.Hold) rather than rejected, so a false
+// positive costs a review, not a lost post.
+package contentcheck
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"feedback-io.backend/config"
+	"feedback-io.backend/metrics"
+	"feedback-io.backend/ratelimit"
+)
+
+// Content is what a user is about to post. Kind is a models.Report* target type; Title is
+// empty for comments and replies. UserId is the authenticated user, 0 for anonymous
+// content, and Ip the client address.
+type Content struct {
+	Kind   string
+	UserId uint
+	Ip     string
+	Title  string
+	Body   string
+}
+
+// Text returns the title and body as one text.
+func (c Content) Text() string {
+	if c.Title == "" {
+		return c.Body
+	}
+	return c.Title + "\n" + c.Body
+}
+
+// Check inspects content and returns why it should be held for moderation, or "" when it
+// passes.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context, content Content) (reason string, err error)
+}
+
+// Verdict is the outcome of a Pipeline. Reasons has one entry per check that flagged the
+// content, prefixed by the check name.
+type Verdict struct {
+	Flagged bool
+	Reasons []string
+}
+
+// Pipeline runs checks in order. Every check runs, so the moderators see all the reasons.
+// A check that takes longer than Timeout, when set, counts as failed.
+type Pipeline struct {
+	Checks  []Check
+	Timeout time.Duration
+}
+
+// Use appends checks to the pipeline, e.g. a Classify check at startup.
+func (p *Pipeline) Use(checks ...Check) {
+	p.Checks = append(p.Checks, checks...)
+}
+
+// Run runs every check on content. A check that fails or times out is logged and skipped:
+// posting must not depend on, say, a classifier being reachable.
+func (p *Pipeline) Run(ctx context.Context, content Content) Verdict {
+	var verdict Verdict
+	for _, check := range p.Checks {
+		reason, err := p.run(ctx, check, content)
+		if err != nil {
+			slog.WarnContext(ctx, "content check failed", "check", check.Name, "kind", content.Kind, "error", err)
+			continue
+		}
+		if reason == "" {
+			continue
+		}
+		verdict.Flagged = true
+		verdict.Reasons = append(verdict.Reasons, check.Name+": "+reason)
+		metrics.ContentFlagged(check.Name)
+	}
+	return verdict
+}
+
+// run runs check within p.Timeout. The check gets a context that is cancelled at the
+// deadline; one that ignores it is abandoned rather than waited for.
+func (p *Pipeline) run(ctx context.Context, check Check, content Content) (string, error) {
+	if p.Timeout <= 0 {
+		return check.Run(ctx, content)
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.Timeout)
+	defer cancel()
+
+	type result struct {
+		reason string
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		reason, err := check.Run(ctx, content)
+		done <- result{reason, err}
+	}()
+	select {
+	case r := <-done:
+		return r.reason, r.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// Default is the pipeline used for user content. It is nil (nothing is checked) until
+// Setup runs.
+var Default *Pipeline
+
+// Run runs the Default pipeline, passing everything when there is none.
+func Run(ctx context.Context, content Content) Verdict {
+	if Default == nil {
+		return Verdict{}
+	}
+	return Default.Run(ctx, content)
+}
+
+// Setup builds the Default pipeline from the environment:
+//   - CONTENT_BLOCKED_WORDS (comma-separated) and CONTENT_BLOCKED_WORDS_FILE (one word or
+//     phrase per line, # for comments) for WordList;
+//   - CONTENT_MAX_LINKS (3 by default, -1 to disable) for Links;
+//   - CONTENT_REPETITION_CHECK (true by default) for Repetition;
+//   - CONTENT_VELOCITY_LIMIT (5 by default, 0 to disable) posts per
+//     CONTENT_VELOCITY_WINDOW (10m by default) for Velocity, counting anonymous posts
+//     per address in store.
+//
+// Each check gets CONTENT_CHECK_TIMEOUT (2s by default, 0 to disable). It must run after
+// the database is connected. Every check is off when CONTENT_CHECKS is false.
+func Setup(store ratelimit.Store) {
+	Default = &Pipeline{Timeout: config.GetEnvDuration("CONTENT_CHECK_TIMEOUT", 2*time.Second)}
+	if !config.GetEnvBool("CONTENT_CHECKS", true) {
+		return
+	}
+
+	words := config.GetEnvList("CONTENT_BLOCKED_WORDS", nil)
+	if path := os.Getenv("CONTENT_BLOCKED_WORDS_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatalf("Error reading CONTENT_BLOCKED_WORDS_FILE: %v", err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+				words = append(words, line)
+			}
+		}
+	}
+	if len(words) > 0 {
+		Default.Use(WordList(words))
+	}
+
+	if max := config.GetEnvInt("CONTENT_MAX_LINKS", 3); max >= 0 {
+		Default.Use(Links(max))
+	}
+	if config.GetEnvBool("CONTENT_REPETITION_CHECK", true) {
+		Default.Use(Repetition())
+	}
+	if limit := config.GetEnvInt("CONTENT_VELOCITY_LIMIT", 5); limit > 0 {
+		Default.Use(Velocity(config.DB, store, limit, config.GetEnvDuration("CONTENT_VELOCITY_WINDOW", 10*time.Minute)))
+	}
+}
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"slices"
+	"strconv"
+	"time"
+
+	"feedback-io.backend/analytics"
+	"feedback-io.backend/apierror"
+	sql "feedback-io.backend/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// analyticsDefaultDays is the date range when no ?from= is given.
+	analyticsDefaultDays = 30
+	// analyticsMaxDays bounds the date range, and so the size of a daily series.
+	analyticsMaxDays = 2 * 366
+)
+
+// GetAnalyticsSummary counts suggestions created, votes and comments in a date range,
+// in total and per category and status.
+func GetAnalyticsSummary(c *fiber.Ctx) error {
+	filter, err := analyticsFilter(c)
+	if err != nil {
+		return err
+	}
+
+	summary, err := analytics.Summarize(c.UserContext(), sql.DB, filter)
+	if err != nil {
+		return apierror.Internal("Failed to compute analytics", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    summary,
+	})
+}
+
+// GetAnalyticsTimeseries counts the same activity per day or week, optionally per
+// category or status.
+func GetAnalyticsTimeseries(c *fiber.Ctx) error {
+	filter, err := analyticsFilter(c)
+	if err != nil {
+		return err
+	}
+
+	interval := c.Query("interval", analytics.Day)
+	if !slices.Contains(analytics.Intervals, interval) {
+		return apierror.Validation("Invalid interval", fiber.Map{"interval": analytics.Intervals})
+	}
+	groupBy := c.Query("group_by")
+	if groupBy != "" && !slices.Contains(analytics.GroupBys, groupBy) {
+		return apierror.Validation("Invalid group_by", fiber.Map{"group_by": analytics.GroupBys})
+	}
+
+	series, err := analytics.Timeseries(c.UserContext(), sql.DB, filter, interval, groupBy)
+	if err != nil {
+		return apierror.Internal("Failed to compute analytics", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    series,
+	})
+}
+
+// analyticsFilter reads ?from=, ?to= (YYYY-MM-DD, both included; the last 30 days by
+// default) and ?category=.
+func analyticsFilter(c *fiber.Ctx) (analytics.Filter, error) {
+	var filter analytics.Filter
+
+	today := time.Now()
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+
+	filter.To = today
+	if value := c.Query("to"); value != "" {
+		to, err := time.ParseInLocation(analytics.DateLayout, value, time.Local)
+		if err != nil {
+			return filter, apierror.BadRequest("Invalid to parameter: expected YYYY-MM-DD")
+		}
+		filter.To = to
+	}
+
+	filter.From = filter.To.AddDate(0, 0, -(analyticsDefaultDays - 1))
+	if value := c.Query("from"); value != "" {
+		from, err := time.ParseInLocation(analytics.DateLayout, value, time.Local)
+		if err != nil {
+			return filter, apierror.BadRequest("Invalid from parameter: expected YYYY-MM-DD")
+		}
+		filter.From = from
+	}
+
+	if filter.From.After(filter.To) {
+		return filter, apierror.Validation("from must not be after to", fiber.Map{"from": "on or before to"})
+	}
+	if filter.To.Sub(filter.From) > analyticsMaxDays*24*time.Hour {
+		return filter, apierror.Validation("Date range is too long", fiber.Map{"to": "at most 2 years after from"})
+	}
+
+	category, err := strconv.Atoi(c.Query("category", "0"))
+	if err != nil || category < 0 {
+		return filter, apierror.BadRequest("Invalid category ID format")
+	}
+	filter.CategoryId = uint(category)
+
+	return filter, nil
+}
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"strconv"
+	"time"
+
+	"feedback-io.backend/apierror"
+	sql "feedback-io.backend/config"
+	"feedback-io.backend/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// auditDateLayout is the format of the ?from= and ?to= days of GetAuditEvents.
+const auditDateLayout = "2006-01-02"
+
+// GetAuditEvents lists the audit log, newest first. Optional filters: ?actor_id=,
+// ?action=, ?target_type=, ?target_id= and ?from= / ?to= (YYYY-MM-DD, both included).
+func GetAuditEvents(c *fiber.Ctx) error {
+	offset, err_offset := strconv.Atoi(c.Query("offset", "0"))
+	limit, err_limit := strconv.Atoi(c.Query("limit", "20"))
+	if err_offset != nil || err_limit != nil {
+		return apierror.BadRequest("Invalid offset parameter or limit parameter")
+	}
+
+	query := sql.DB.WithContext(c.UserContext()).Model(&models.AuditEvent{})
+	if value := c.Query("actor_id"); value != "" {
+		actor, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return apierror.BadRequest("Invalid actor_id parameter")
+		}
+		query = query.Where("actor_id = ?", actor)
+	}
+	for _, filter := range []string{"action", "target_type", "target_id"} {
+		if value := c.Query(filter); value != "" {
+			query = query.Where(filter+" = ?", value)
+		}
+	}
+	if value := c.Query("from"); value != "" {
+		from, err := time.ParseInLocation(auditDateLayout, value, time.Local)
+		if err != nil {
+			return apierror.BadRequest("Invalid from parameter: expected YYYY-MM-DD")
+		}
+		query = query.Where("created_at >= ?", models.DateTime{Time: from})
+	}
+	if value := c.Query("to"); value != "" {
+		to, err := time.ParseInLocation(auditDateLayout, value, time.Local)
+		if err != nil {
+			return apierror.BadRequest("Invalid to parameter: expected YYYY-MM-DD")
+		}
+		query = query.Where("created_at < ?", models.DateTime{Time: to.AddDate(0, 0, 1)})
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return apierror.Internal("Failed to fetch audit events count", err)
+	}
+
+	var items []models.AuditEvent
+	if err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&items).Error; err != nil {
+		return apierror.Internal("Failed to fetch audit events", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"count":   count,
+		"data":    items,
+	})
+}
@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"feedback-io.backend/apierror"
+	"feedback-io.backend/auth"
+	"feedback-io.backend/cache"
+	sql "feedback-io.backend/config"
+	"feedback-io.backend/contentcheck"
+	"feedback-io.backend/events"
+	"feedback-io.backend/models"
+	"feedback-io.backend/moderation"
+	"feedback-io.backend/notifications"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type CreateCommentInput struct {
+	Content string `json:"content"`
+}
+
+// CreateComment adds a comment by the caller to a suggestion. Content flagged by the content checks is
+// stored hidden and queued for moderation, without notifying anyone.
+func CreateComment(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return apierror.BadRequest("Invalid suggestion ID")
+	}
+
+	var input CreateCommentInput
+	if err := c.BodyParser(&input); err != nil {
+		return apierror.BadRequest("Failed to parse request body")
+	}
+	if strings.TrimSpace(input.Content) == "" {
+		return apierror.Validation("Comment content is required", fiber.Map{"content": "required"})
+	}
+
+	db := sql.DB.WithContext(c.UserContext())
+
+	suggestion, err := findSuggestion(db, id)
+	if err != nil {
+		return err
+	}
+
+	userID, _ := auth.UserID(c)
+	comment := models.Comment{
+		Content:      input.Content,
+		UserId:       userID,
+		SuggestionId: suggestion.Id,
+	}
+	verdict := contentcheck.Run(c.UserContext(), contentcheck.Content{
+		Kind:   models.ReportComment,
+		UserId: userID,
+		Ip:     c.IP(),
+		Body:   input.Content,
+	})
+	if verdict.Flagged {
+		comment.HiddenAt = &models.DateTime{Time: time.Now()}
+	}
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("User", "Suggestion", "Replies").Create(&comment).Error; err != nil {
+			return err
+		}
+		if verdict.Flagged {
+			return moderation.Hold(tx, models.ReportComment, comment.Id, verdict.Reasons)
+		}
+		return nil
+	}); err != nil {
+		return apierror.FromDB(err, "Failed to create comment")
+	}
+	if verdict.Flagged {
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"success": true,
+			"message": "Comment is awaiting moderation",
+			"data":    comment,
+		})
+	}
+	cache.Invalidate(c.UserContext(), cache.SuggestionsPrefix)
+	events.Publish(events.CommentCreated, suggestion.Id, suggestion.CategoryId, comment)
+	notifications.Notify(c.UserContext(), suggestion.Id, comment.UserId, models.NotificationCommentAdded, fiber.Map{
+		"suggestion_id":    suggestion.Id,
+		"suggestion_title": suggestion.Title,
+		"comment_id":       comment.Id,
+		"content":          comment.Content,
+	})
+	notifications.Follow(c.UserContext(), comment.UserId, suggestion.Id, models.SubscriptionCommenter)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    comment,
+	})
+}
+
+type CreateReplyInput struct {
+	Content string `json:"content"`
+}
+
+// CreateReply answers a comment as the caller. Like CreateComment, flagged content is held for moderation.
+func CreateReply(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return apierror.BadRequest("Invalid suggestion ID")
+	}
+	commentID, err := strconv.Atoi(c.Params("comment"))
+	if err != nil {
+		return apierror.BadRequest("Invalid comment ID")
+	}
+
+	var input CreateReplyInput
+	if err := c.BodyParser(&input); err != nil {
+		return apierror.BadRequest("Failed to parse request body")
+	}
+	if strings.TrimSpace(input.Content) == "" {
+		return apierror.Validation("Reply content is required", fiber.Map{"content": "required"})
+	}
+
+	db := sql.DB.WithContext(c.UserContext())
+
+	suggestion, err := findSuggestion(db, id)
+	if err != nil {
+		return err
+	}
+
+	var comment models.Comment
+	if err := db.Scopes(models.Visible).Where("suggestion_id = ?", suggestion.Id).First(&comment, commentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apierror.NotFound("Comment not found")
+		}
+		return apierror.Internal("Failed to fetch comment", err)
+	}
+
+	userID, _ := auth.UserID(c)
+	reply := models.Reply{
+		Content:   input.Content,
+		CommentId: comment.Id,
+		UserId:    userID,
+	}
+	verdict := contentcheck.Run(c.UserContext(), contentcheck.Content{
+		Kind:   models.ReportReply,
+		UserId: userID,
+		Ip:     c.IP(),
+		Body:   input.Content,
+	})
+	if verdict.Flagged {
+		reply.HiddenAt = &models.DateTime{Time: time.Now()}
+	}
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Comment", "User").Create(&reply).Error; err != nil {
+			return err
+		}
+		if verdict.Flagged {
+			return moderation.Hold(tx, models.ReportReply, reply.Id, verdict.Reasons)
+		}
+		return nil
+	}); err != nil {
+		return apierror.FromDB(err, "Failed to create reply")
+	}
+	if verdict.Flagged {
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"success": true,
+			"message": "Reply is awaiting moderation",
+			"data":    reply,
+		})
+	}
+	cache.Invalidate(c.UserContext(), cache.SuggestionsPrefix)
+	notifications.NotifyUser(c.UserContext(), comment.UserId, suggestion.Id, reply.UserId, models.NotificationReplyAdded, fiber.Map{
+		"suggestion_id":    suggestion.Id,
+		"suggestion_title": suggestion.Title,
+		"comment_id":       comment.Id,
+		"reply_id":         reply.Id,
+		"content":          reply.Content,
+	})
+	notifications.EmailReply(c.UserContext(), suggestion, comment, reply)
+	notifications.Follow(c.UserContext(), reply.UserId, suggestion.Id, models.SubscriptionCommenter)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    reply,
+	})
+}
@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"feedback-io.backend/apierror"
+	"feedback-io.backend/events"
+	"feedback-io.backend/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// heartbeatInterval keeps idle connections open through proxies.
+const heartbeatInterval = 15 * time.Second
+
+// resetEvent tells a resuming client that events it missed are no longer available.
+const resetEvent = "reset"
+
+// StreamEvents streams suggestion and comment events as Server-Sent Events.
+// Optional filters: ?suggestion_id= and ?category=. Clients resume with the
+// Last-Event-ID header (or ?last_event_id=) after reconnecting; when the events they
+// missed are no longer logged they get a "reset" event and should reload instead.
+func StreamEvents(c *fiber.Ctx) error {
+	suggestionID, err := strconv.ParseUint(c.Query("suggestion_id", "0"), 10, 32)
+	if err != nil {
+		return apierror.BadRequest("Invalid suggestion_id parameter")
+	}
+	category, err := strconv.ParseUint(c.Query("category", "0"), 10, 32)
+	if err != nil {
+		return apierror.BadRequest("Invalid category parameter")
+	}
+
+	lastEventID := c.Get("Last-Event-ID", c.Query("last_event_id"))
+	var lastID uint64
+	if lastEventID != "" {
+		if lastID, err = strconv.ParseUint(lastEventID, 10, 64); err != nil {
+			return apierror.BadRequest("Invalid Last-Event-ID")
+		}
+	}
+
+	backlog, sub := events.Default.Subscribe(events.Filter{
+		SuggestionId: uint(suggestionID),
+		CategoryId:   uint(category),
+	}, lastID)
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	log := logger.FromContext(c.UserContext())
+
+	c.Context().SetBodyStreamWriter(fasthttp.StreamWriter(func(w *bufio.Writer) {
+		defer sub.Close()
+
+		// tell EventSource how long to wait before reconnecting
+		fmt.Fprint(w, "retry: 3000\n\n")
+		if backlog.Gap {
+			// the missed events are gone; the client reloads and resumes from the latest one
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: {\"type\":%q,\"last_event_id\":%d}\n\n",
+				backlog.LastID, resetEvent, resetEvent, backlog.LastID)
+		} else {
+			for _, event := range backlog.Events {
+				if err := writeEvent(w, event); err != nil {
+					return
+				}
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(heartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case event, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				if err := writeEvent(w, event); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": heartbeat\n\n")
+			}
+
+			// a failed flush means the client went away
+			if err := w.Flush(); err != nil {
+				log.Debug("event stream closed", "error", err)
+				return
+			}
+		}
+	}))
+
+	return nil
+}
+
+func writeEvent(w *bufio.Writer, event events.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
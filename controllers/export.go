@@ -0,0 +1,293 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"feedback-io.backend/apierror"
+	sql "feedback-io.backend/config"
+	"feedback-io.backend/logger"
+	"feedback-io.backend/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+	"gorm.io/gorm"
+)
+
+// Export formats (?format=).
+const (
+	ExportCSV    = "csv"
+	ExportJSON   = "json"
+	ExportNDJSON = "ndjson"
+)
+
+// ExportFormats lists the accepted ?format= values of ExportSuggestions.
+var ExportFormats = []string{ExportCSV, ExportJSON, ExportNDJSON}
+
+var exportContentTypes = map[string]string{
+	ExportCSV:    "text/csv; charset=utf-8",
+	ExportJSON:   fiber.MIMEApplicationJSON,
+	ExportNDJSON: "application/x-ndjson",
+}
+
+// exportBatchSize is how many suggestions are loaded, with their comments, at a time.
+const exportBatchSize = 500
+
+// exportTimeout bounds the database work of one export.
+const exportTimeout = 10 * time.Minute
+
+// ExportedSuggestion is one suggestion in a JSON or NDJSON export.
+type ExportedSuggestion struct {
+	Id         uint               `json:"id"`
+	Title      string             `json:"title"`
+	Content    string             `json:"content"`
+	CategoryId uint               `json:"category_id"`
+	Status     string             `json:"status"`
+	Votes      int                `json:"votes"`
+	UserId     uint               `json:"user_id"`
+	CreatedAt  models.DateTime    `json:"created_at"`
+	UpdatedAt  models.DateTime    `json:"updated_at"`
+	Comments   *[]ExportedComment `json:"comments,omitempty"`
+}
+
+type ExportedComment struct {
+	Id        uint            `json:"id"`
+	Content   string          `json:"content"`
+	UserId    uint            `json:"user_id"`
+	CreatedAt models.DateTime `json:"created_at"`
+	Replies   []ExportedReply `json:"replies,omitempty"`
+}
+
+type ExportedReply struct {
+	Id        uint            `json:"id"`
+	Content   string          `json:"content"`
+	UserId    uint            `json:"user_id"`
+	CreatedAt models.DateTime `json:"created_at"`
+}
+
+// ExportSuggestions streams every suggestion, ordered by id, as CSV, a JSON array or
+// newline delimited JSON. Filters: ?category= like GetSuggestions, and
+// ?include_comments=true to add comments and their replies. Suggestions are read in
+// batches, so memory use doesn't grow with the number of suggestions.
+func ExportSuggestions(c *fiber.Ctx) error {
+	format := c.Query("format", ExportCSV)
+	if !slices.Contains(ExportFormats, format) {
+		return apierror.Validation("Invalid format", fiber.Map{"format": ExportFormats})
+	}
+
+	category, err := strconv.Atoi(c.Query("category", "0"))
+	if err != nil {
+		return apierror.BadRequest("Invalid category ID format")
+	}
+	withComments := c.QueryBool("include_comments")
+
+	filename := "suggestions-" + time.Now().Format("2006-01-02") + "." + format
+	c.Set(fiber.HeaderContentType, exportContentTypes[format])
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	log := logger.FromContext(c.UserContext())
+
+	// the body is written after the handler returns, so nothing may use c from here on
+	c.Context().SetBodyStreamWriter(fasthttp.StreamWriter(func(w *bufio.Writer) {
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		defer cancel()
+
+		query := sql.DB.WithContext(ctx)
+		if category != 0 {
+			query = query.Where("category_id = ?", category)
+		}
+		if withComments {
+			query = query.
+				Preload("Comments", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+				Preload("Comments.Replies", func(db *gorm.DB) *gorm.DB { return db.Order("id") })
+		}
+
+		export := newExporter(w, format, withComments)
+		var batch []models.Suggestion
+		err := query.FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
+			for _, suggestion := range batch {
+				if err := export.write(suggestion); err != nil {
+					return err
+				}
+			}
+			// a failed flush means the client went away
+			return w.Flush()
+		}).Error
+		if err == nil {
+			err = export.close()
+		}
+		if err != nil {
+			log.Warn("suggestion export stopped", "format", format, "error", err)
+		}
+	}))
+
+	return nil
+}
+
+// exporter writes suggestions in one of the export formats.
+type exporter struct {
+	w            *bufio.Writer
+	csv          *csv.Writer
+	format       string
+	withComments bool
+	count        int
+}
+
+func newExporter(w *bufio.Writer, format string, withComments bool) *exporter {
+	e := &exporter{w: w, format: format, withComments: withComments}
+	if format == ExportCSV {
+		e.csv = csv.NewWriter(w)
+	}
+	return e
+}
+
+// csvHeader has one row per suggestion; with comments, one row per suggestion, comment
+// and reply, told apart by the type column.
+var (
+	csvHeader             = []string{"id", "title", "content", "category_id", "status", "votes", "user_id", "created_at", "updated_at"}
+	csvHeaderWithComments = []string{"type", "suggestion_id", "comment_id", "reply_id", "title", "content", "category_id", "status", "votes", "user_id", "created_at"}
+)
+
+func (e *exporter) write(suggestion models.Suggestion) error {
+	defer func() { e.count++ }()
+
+	switch e.format {
+	case ExportCSV:
+		return e.writeCSV(suggestion)
+	case ExportJSON:
+		data, err := json.Marshal(exported(suggestion))
+		if err != nil {
+			return err
+		}
+		separator := ",\n"
+		if e.count == 0 {
+			separator = "[\n"
+		}
+		e.w.WriteString(separator)
+		_, err = e.w.Write(data)
+		return err
+	default:
+		// Encode ends every value with a newline
+		return json.NewEncoder(e.w).Encode(exported(suggestion))
+	}
+}
+
+func (e *exporter) close() error {
+	switch e.format {
+	case ExportCSV:
+		if e.count == 0 {
+			e.writeHeader()
+		}
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	case ExportJSON:
+		closing := "\n]\n"
+		if e.count == 0 {
+			closing = "[]\n"
+		}
+		if _, err := e.w.WriteString(closing); err != nil {
+			return err
+		}
+	}
+	return e.w.Flush()
+}
+
+func (e *exporter) writeHeader() {
+	if e.withComments {
+		e.csv.Write(csvHeaderWithComments)
+	} else {
+		e.csv.Write(csvHeader)
+	}
+}
+
+func (e *exporter) writeCSV(suggestion models.Suggestion) error {
+	if e.count == 0 {
+		e.writeHeader()
+	}
+
+	id := strconv.FormatUint(uint64(suggestion.Id), 10)
+	category := strconv.FormatUint(uint64(suggestion.CategoryId), 10)
+	votes := strconv.Itoa(suggestion.Votes)
+	user := strconv.FormatUint(uint64(suggestion.UserId), 10)
+
+	if !e.withComments {
+		e.csv.Write([]string{id, csvSafe(suggestion.Title), csvSafe(suggestion.Content), category,
+			suggestion.Status, votes, user, csvTime(suggestion.CreatedAt), csvTime(suggestion.UpdatedAt)})
+		e.csv.Flush()
+		return e.csv.Error()
+	}
+
+	e.csv.Write([]string{"suggestion", id, "", "", csvSafe(suggestion.Title), csvSafe(suggestion.Content),
+		category, suggestion.Status, votes, user, csvTime(suggestion.CreatedAt)})
+	if suggestion.Comments != nil {
+		for _, comment := range *suggestion.Comments {
+			commentID := strconv.FormatUint(uint64(comment.Id), 10)
+			e.csv.Write([]string{"comment", id, commentID, "", "", csvSafe(comment.Content), "", "", "",
+				strconv.FormatUint(uint64(comment.UserId), 10), csvTime(comment.CreatedAt)})
+			if comment.Replies == nil {
+				continue
+			}
+			for _, reply := range *comment.Replies {
+				e.csv.Write([]string{"reply", id, commentID, strconv.FormatUint(uint64(reply.Id), 10), "", csvSafe(reply.Content), "", "", "",
+					strconv.FormatUint(uint64(reply.UserId), 10), csvTime(reply.CreatedAt)})
+			}
+		}
+	}
+	e.csv.Flush()
+	return e.csv.Error()
+}
+
+func exported(suggestion models.Suggestion) ExportedSuggestion {
+	out := ExportedSuggestion{
+		Id:         suggestion.Id,
+		Title:      suggestion.Title,
+		Content:    suggestion.Content,
+		CategoryId: suggestion.CategoryId,
+		Status:     suggestion.Status,
+		Votes:      suggestion.Votes,
+		UserId:     suggestion.UserId,
+		CreatedAt:  suggestion.CreatedAt,
+		UpdatedAt:  suggestion.UpdatedAt,
+	}
+	if suggestion.Comments == nil {
+		return out
+	}
+
+	comments := []ExportedComment{}
+	for _, comment := range *suggestion.Comments {
+		item := ExportedComment{Id: comment.Id, Content: comment.Content, UserId: comment.UserId, CreatedAt: comment.CreatedAt}
+		if comment.Replies != nil {
+			for _, reply := range *comment.Replies {
+				item.Replies = append(item.Replies, ExportedReply{
+					Id: reply.Id, Content: reply.Content, UserId: reply.UserId, CreatedAt: reply.CreatedAt,
+				})
+			}
+		}
+		comments = append(comments, item)
+	}
+	out.Comments = &comments
+	return out
+}
+
+// csvSafe stops spreadsheets from evaluating user content as a formula.
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func csvTime(t models.DateTime) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	sql "feedback-io.backend/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Healthz reports that the process is alive. It never touches the database.
+func Healthz(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"status":  "ok",
+	})
+}
+
+// Readyz reports whether the service can serve traffic: the database must answer a ping
+// within READINESS_TIMEOUT and every model table/column must already be migrated, which
+// the `migrate` command (or DB_AUTO_MIGRATE) does.
+func Readyz(c *fiber.Ctx) error {
+	timeout := sql.GetEnvDuration("READINESS_TIMEOUT", 2*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+	defer cancel()
+
+	ready := true
+	components := fiber.Map{}
+
+	database := fiber.Map{"status": "up"}
+	start := time.Now()
+	if err := pingDatabase(ctx); err != nil {
+		ready = false
+		database["status"] = "down"
+		database["error"] = err.Error()
+	}
+	database["latency_ms"] = time.Since(start).Milliseconds()
+	components["database"] = database
+
+	migrations := fiber.Map{"status": "up"}
+	if database["status"] == "up" {
+		pending, err := sql.PendingMigrations(sql.DB.WithContext(ctx))
+		if err != nil {
+			ready = false
+			migrations["status"] = "down"
+			migrations["error"] = err.Error()
+		} else if len(pending) > 0 {
+			ready = false
+			migrations["status"] = "pending"
+			migrations["pending"] = pending
+			migrations["error"] = "run `migrate` to create the missing tables and columns"
+		}
+	} else {
+		migrations["status"] = "unknown"
+	}
+	components["migrations"] = migrations
+
+	status := fiber.StatusOK
+	overall := "ok"
+	if !ready {
+		status = fiber.StatusServiceUnavailable
+		overall = "unavailable"
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"success":    ready,
+		"status":     overall,
+		"components": components,
+	})
+}
+
+func pingDatabase(ctx context.Context) error {
+	if sql.DB == nil {
+		return errors.New("database not connected")
+	}
+	db, err := sql.DB.DB()
+	if err != nil {
+		return err
+	}
+	return db.PingContext(ctx)
+}
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"feedback-io.backend/apierror"
+	"feedback-io.backend/audit"
+	"feedback-io.backend/cache"
+	sql "feedback-io.backend/config"
+	"feedback-io.backend/importer"
+	"feedback-io.backend/logger"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ImportSuggestions imports suggestions and their comments from the CSV or JSON request
+// body (see importer.Import), in one transaction. The format is ?format=, or else
+// derived from the Content-Type. With ?dry_run=true everything is checked and rolled
+// back. When a row is invalid nothing is imported and the report, with the error of
+// every row, is the details of a 422.
+func ImportSuggestions(c *fiber.Ctx) error {
+	format := c.Query("format")
+	if format == "" {
+		format = importer.JSON
+		if strings.HasPrefix(c.Get(fiber.HeaderContentType), "text/csv") {
+			format = importer.CSV
+		}
+	}
+	if !slices.Contains(importer.Formats, format) {
+		return apierror.Validation("Invalid format", fiber.Map{"format": importer.Formats})
+	}
+
+	opts := importer.Options{
+		DryRun:  c.QueryBool("dry_run"),
+		MaxRows: sql.GetEnvInt("IMPORT_MAX_ROWS", 10000),
+		Actor:   audit.ActorOf(c),
+	}
+	report, err := importer.Import(c.UserContext(), sql.DB, format, bytes.NewReader(c.Body()), opts)
+	switch {
+	case errors.Is(err, importer.ErrMalformed):
+		return apierror.BadRequest(err.Error())
+	case errors.Is(err, importer.ErrTooManyRows):
+		return apierror.Validation("Too many rows", fiber.Map{"rows": fmt.Sprintf("at most %d", opts.MaxRows)})
+	case err != nil:
+		return apierror.Internal("Failed to import suggestions", err)
+	}
+
+	if len(report.Errors) > 0 {
+		return apierror.Validation(fmt.Sprintf("%d errors in the import; nothing was imported", len(report.Errors)), report)
+	}
+
+	status := fiber.StatusOK
+	if report.Committed {
+		status = fiber.StatusCreated
+		cache.Invalidate(c.UserContext(), cache.SuggestionsPrefix)
+		logger.FromContext(c.UserContext()).Info("imported suggestions", "suggestions", report.Suggestions,
+			"comments", report.Comments, "categories_created", len(report.CategoriesCreated), "users_created", len(report.UsersCreated))
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"success": true,
+		"data":    report,
+	})
+}
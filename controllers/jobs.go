@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+
+	"feedback-io.backend/apierror"
+	"feedback-io.backend/audit"
+	sql "feedback-io.backend/config"
+	"feedback-io.backend/jobs"
+	"feedback-io.backend/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// JobStatsResponse is the data of GetJobStats: the number of jobs per status.
+type JobStatsResponse map[string]int64
+
+// GetJobs lists background jobs, newest first.
+// Optional filters: ?status=pending|running|succeeded|failed and ?kind=.
+func GetJobs(c *fiber.Ctx) error {
+	offset, err_offset := strconv.Atoi(c.Query("offset", "0"))
+	limit, err_limit := strconv.Atoi(c.Query("limit", "20"))
+	if err_offset != nil || err_limit != nil {
+		return apierror.BadRequest("Invalid offset parameter or limit parameter")
+	}
+
+	query := sql.DB.WithContext(c.UserContext()).Model(&models.Job{})
+	if status := c.Query("status"); status != "" {
+		if !slices.Contains(models.JobStatuses, status) {
+			return apierror.Validation("Invalid job status", fiber.Map{"status": models.JobStatuses})
+		}
+		query = query.Where("status = ?", status)
+	}
+	if kind := c.Query("kind"); kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return apierror.Internal("Failed to fetch jobs count", err)
+	}
+
+	var items []models.Job
+	if err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&items).Error; err != nil {
+		return apierror.Internal("Failed to fetch jobs", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"count":   count,
+		"data":    items,
+	})
+}
+
+// GetJobStats counts jobs per status, e.g. to alert on a growing number of failed jobs.
+func GetJobStats(c *fiber.Ctx) error {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	if err := sql.DB.WithContext(c.UserContext()).Model(&models.Job{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&rows).Error; err != nil {
+		return apierror.Internal("Failed to count jobs", err)
+	}
+
+	stats := JobStatsResponse{}
+	for _, status := range models.JobStatuses {
+		stats[status] = 0
+	}
+	for _, row := range rows {
+		stats[row.Status] = row.Count
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    stats,
+	})
+}
+
+func GetJob(c *fiber.Ctx) error {
+	job, err := findJob(c)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    job,
+	})
+}
+
+// RetryJob schedules a failed job again with a fresh set of attempts.
+func RetryJob(c *fiber.Ctx) error {
+	job, err := findJob(c)
+	if err != nil {
+		return err
+	}
+	if job.Status != models.JobFailed {
+		return apierror.Conflict("Only failed jobs can be retried")
+	}
+
+	if err := jobs.Retry(sql.DB.WithContext(c.UserContext()), audit.ActorOf(c), &job); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apierror.Conflict("Only failed jobs can be retried")
+		}
+		return apierror.Internal("Failed to retry job", err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"success": true,
+		"data":    job,
+	})
+}
+
+func findJob(c *fiber.Ctx) (models.Job, error) {
+	var job models.Job
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return job, apierror.BadRequest("Invalid job ID")
+	}
+	if err := sql.DB.WithContext(c.UserContext()).First(&job, id).Error; err != nil {
+		return job, apierror.FromDB(err, "Failed to fetch job")
+	}
+	return job, nil
+}
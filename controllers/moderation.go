@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"feedback-io.backend/apierror"
+	"feedback-io.backend/audit"
+	"feedback-io.backend/auth"
+	sql "feedback-io.backend/config"
+	"feedback-io.backend/models"
+	"feedback-io.backend/moderation"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// reportDetailsLimit matches the size of the details column.
+const reportDetailsLimit = 1000
+
+type CreateReportInput struct {
+	TargetType string `json:"target_type"`
+	TargetId   uint   `json:"target_id"`
+	Reason     string `json:"reason"`
+	Details    string `json:"details"`
+}
+
+type ModerationActionInput struct {
+	Action string `json:"action"`
+	Note   string `json:"note"`
+}
+
+// CreateReport flags a suggestion, comment or reply for the moderators. Content with
+// enough open reports is hidden until a moderator reviews it.
+func CreateReport(c *fiber.Ctx) error {
+	var input CreateReportInput
+	if err := c.BodyParser(&input); err != nil {
+		return apierror.BadRequest("Failed to parse request body")
+	}
+	if !slices.Contains(models.ReportTargets, input.TargetType) {
+		return apierror.Validation("Invalid target_type", fiber.Map{"target_type": models.ReportTargets})
+	}
+	if input.TargetId == 0 {
+		return apierror.Validation("target_id is required", fiber.Map{"target_id": "required"})
+	}
+	if !slices.Contains(models.ReportReasons, input.Reason) {
+		return apierror.Validation("Invalid reason", fiber.Map{"reason": models.ReportReasons})
+	}
+	details := strings.TrimSpace(input.Details)
+	if len(details) > reportDetailsLimit {
+		return apierror.Validation("Details are too long", fiber.Map{"details": "at most 1000 characters"})
+	}
+
+	reporterID, _ := auth.UserID(c)
+	report := models.Report{
+		ReporterId: reporterID,
+		TargetType: input.TargetType,
+		TargetId:   input.TargetId,
+		Reason:     input.Reason,
+		Details:    details,
+	}
+	if _, err := moderation.File(c.UserContext(), sql.DB, &report); err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return apierror.NotFound("Content not found")
+		case errors.Is(err, gorm.ErrDuplicatedKey):
+			return apierror.Conflict("You already reported this content")
+		}
+		return apierror.FromDB(err, "Failed to create report")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// GetModerationQueue lists content with open reports, longest waiting first.
+// Optional filter: ?target_type=suggestion|comment|reply.
+func GetModerationQueue(c *fiber.Ctx) error {
+	offset, err_offset := strconv.Atoi(c.Query("offset", "0"))
+	limit, err_limit := strconv.Atoi(c.Query("limit", "20"))
+	if err_offset != nil || err_limit != nil {
+		return apierror.BadRequest("Invalid offset parameter or limit parameter")
+	}
+
+	targetType := c.Query("target_type")
+	if targetType != "" && !slices.Contains(models.ReportTargets, targetType) {
+		return apierror.Validation("Invalid target_type", fiber.Map{"target_type": models.ReportTargets})
+	}
+
+	items, count, err := moderation.Queue(c.UserContext(), sql.DB, targetType, offset, limit)
+	if err != nil {
+		return apierror.Internal("Failed to fetch moderation queue", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"count":   count,
+		"data":    items,
+	})
+}
+
+// ModerateContent hides, unhides, deletes or dismisses the reports of a suggestion,
+// comment or reply (:type), resolving its open reports.
+func ModerateContent(c *fiber.Ctx) error {
+	targetType := c.Params("type")
+	if !slices.Contains(models.ReportTargets, targetType) {
+		return apierror.NotFound("Unknown content type")
+	}
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id <= 0 {
+		return apierror.BadRequest("Invalid content ID")
+	}
+
+	var input ModerationActionInput
+	if err := c.BodyParser(&input); err != nil {
+		return apierror.BadRequest("Failed to parse request body")
+	}
+	if !slices.Contains(moderation.Actions, input.Action) {
+		return apierror.Validation("Invalid action", fiber.Map{"action": moderation.Actions})
+	}
+
+	item, err := moderation.Apply(c.UserContext(), sql.DB, audit.ActorOf(c), targetType, uint(id), input.Action, strings.TrimSpace(input.Note))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apierror.NotFound("Content not found")
+		}
+		return apierror.Internal("Failed to moderate content", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    item,
+	})
+}
@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"strconv"
+	"time"
+
+	"feedback-io.backend/apierror"
+	"feedback-io.backend/auth"
+	sql "feedback-io.backend/config"
+	"feedback-io.backend/models"
+	"feedback-io.backend/notifications"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// MarkReadResponse is the data of MarkAllNotificationsRead.
+type MarkReadResponse struct {
+	Marked int64 `json:"marked"`
+}
+
+// GetNotifications lists the caller's notifications, newest first, with the number of
+// unread ones. Optional filter: ?unread=true.
+func GetNotifications(c *fiber.Ctx) error {
+	userID, _ := auth.UserID(c)
+
+	offset, err_offset := strconv.Atoi(c.Query("offset", "0"))
+	limit, err_limit := strconv.Atoi(c.Query("limit", "20"))
+	if err_offset != nil || err_limit != nil {
+		return apierror.BadRequest("Invalid offset parameter or limit parameter")
+	}
+
+	db := sql.DB.WithContext(c.UserContext())
+	unreadOnly := c.QueryBool("unread")
+	inbox := func(unread bool) *gorm.DB {
+		query := db.Model(&models.Notification{}).Where("user_id = ?", userID)
+		if unread {
+			query = query.Where("read_at IS NULL")
+		}
+		return query
+	}
+
+	var unread int64
+	if err := inbox(true).Count(&unread).Error; err != nil {
+		return apierror.Internal("Failed to count unread notifications", err)
+	}
+
+	count := unread
+	if !unreadOnly {
+		if err := inbox(false).Count(&count).Error; err != nil {
+			return apierror.Internal("Failed to count notifications", err)
+		}
+	}
+
+	var items []models.Notification
+	if err := inbox(unreadOnly).Order("id DESC").Limit(limit).Offset(offset).Find(&items).Error; err != nil {
+		return apierror.Internal("Failed to fetch notifications", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"count":   count,
+		"unread":  unread,
+		"data":    items,
+	})
+}
+
+// MarkNotificationRead marks one of the caller's notifications as read.
+func MarkNotificationRead(c *fiber.Ctx) error {
+	userID, _ := auth.UserID(c)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return apierror.BadRequest("Invalid notification ID")
+	}
+
+	db := sql.DB.WithContext(c.UserContext())
+
+	var notification models.Notification
+	if err := db.Where("user_id = ?", userID).First(&notification, id).Error; err != nil {
+		return apierror.FromDB(err, "Failed to fetch notification")
+	}
+
+	if notification.ReadAt == nil {
+		readAt := models.DateTime{Time: time.Now()}
+		if err := db.Model(&notification).Update("read_at", readAt).Error; err != nil {
+			return apierror.Internal("Failed to mark notification as read", err)
+		}
+		notification.ReadAt = &readAt
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    notification,
+	})
+}
+
+// MarkAllNotificationsRead marks every unread notification of the caller as read.
+func MarkAllNotificationsRead(c *fiber.Ctx) error {
+	userID, _ := auth.UserID(c)
+
+	result := sql.DB.WithContext(c.UserContext()).Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", models.DateTime{Time: time.Now()})
+	if result.Error != nil {
+		return apierror.Internal("Failed to mark notifications as read", result.Error)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    MarkReadResponse{Marked: result.RowsAffected},
+	})
+}
+
+// FollowSuggestion subscribes the caller to a suggestion's notifications.
+func FollowSuggestion(c *fiber.Ctx) error {
+	userID, _ := auth.UserID(c)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return apierror.BadRequest("Invalid suggestion ID")
+	}
+
+	db := sql.DB.WithContext(c.UserContext())
+
+	suggestion, err := findSuggestion(db, id)
+	if err != nil {
+		return err
+	}
+
+	subscription, err := notifications.Subscribe(db, userID, suggestion.Id, models.SubscriptionManual)
+	if err != nil {
+		return apierror.FromDB(err, "Failed to follow suggestion")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    subscription,
+	})
+}
+
+// UnfollowSuggestion removes the caller's subscription. Participating again (commenting,
+// voting) subscribes them again.
+func UnfollowSuggestion(c *fiber.Ctx) error {
+	userID, _ := auth.UserID(c)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return apierror.BadRequest("Invalid suggestion ID")
+	}
+
+	if err := sql.DB.WithContext(c.UserContext()).
+		Where("user_id = ? AND suggestion_id = ?", userID, id).
+		Delete(&models.Subscription{}).Error; err != nil {
+		return apierror.Internal("Failed to unfollow suggestion", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Suggestion unfollowed successfully",
+	})
+}
@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"slices"
+
+	"feedback-io.backend/apierror"
+	"feedback-io.backend/auth"
+	sql "feedback-io.backend/config"
+	"feedback-io.backend/models"
+	"feedback-io.backend/notifications"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// UpdatePreferencesInput changes the fields that are present.
+type UpdatePreferencesInput struct {
+	EmailStatusChanges *bool   `json:"email_status_changes"`
+	EmailReplies       *bool   `json:"email_replies"`
+	Digest             *string `json:"digest"`
+}
+
+func GetPreferences(c *fiber.Ctx) error {
+	userID, _ := auth.UserID(c)
+
+	preferences, err := notifications.Preferences(sql.DB.WithContext(c.UserContext()), userID)
+	if err != nil {
+		return apierror.Internal("Failed to fetch preferences", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    preferences,
+	})
+}
+
+func UpdatePreferences(c *fiber.Ctx) error {
+	userID, _ := auth.UserID(c)
+
+	var input UpdatePreferencesInput
+	if err := c.BodyParser(&input); err != nil {
+		return apierror.BadRequest("Failed to parse request body")
+	}
+	if input.Digest != nil && !slices.Contains(models.DigestFrequencies, *input.Digest) {
+		return apierror.Validation("Invalid digest frequency", fiber.Map{"digest": models.DigestFrequencies})
+	}
+
+	db := sql.DB.WithContext(c.UserContext())
+
+	preferences, err := notifications.Preferences(db, userID)
+	if err != nil {
+		return apierror.Internal("Failed to fetch preferences", err)
+	}
+
+	updates := map[string]any{}
+	if input.EmailStatusChanges != nil {
+		preferences.EmailStatusChanges = *input.EmailStatusChanges
+		updates["email_status_changes"] = preferences.EmailStatusChanges
+	}
+	if input.EmailReplies != nil {
+		preferences.EmailReplies = *input.EmailReplies
+		updates["email_replies"] = preferences.EmailReplies
+	}
+	if input.Digest != nil {
+		preferences.Digest = *input.Digest
+		updates["digest"] = preferences.Digest
+	}
+	if len(updates) > 0 {
+		if err := db.Model(&preferences).Updates(updates).Error; err != nil {
+			return apierror.Internal("Failed to update preferences", err)
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    preferences,
+	})
+}
+
+// UnsubscribeState is what an unsubscribe token currently receives.
+type UnsubscribeState struct {
+	Subscribed         bool   `json:"subscribed"`
+	EmailStatusChanges bool   `json:"email_status_changes"`
+	EmailReplies       bool   `json:"email_replies"`
+	Digest             string `json:"digest"`
+}
+
+// unsubscribePage is shown to browsers following the link of an email: GET only asks for
+// confirmation, the form POSTs it.
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"><title>Unsubscribe</title></head>
+<body>
+{{if .Done}}<p>You are unsubscribed from all emails.</p>
+{{else if .Subscribed}}<form method="post" action="{{.Action}}">
+<p>Stop all emails from Feedback, including digests?</p>
+<button type="submit" name="List-Unsubscribe" value="One-Click">Unsubscribe</button>
+</form>
+{{else}}<p>You are already unsubscribed from all emails.</p>
+{{end}}</body>
+</html>
+`))
+
+// wantsHTML reports whether the client is a browser rather than an API or mail client.
+func wantsHTML(c *fiber.Ctx) bool {
+	return c.Accepts(fiber.MIMEApplicationJSON, fiber.MIMETextHTML) == fiber.MIMETextHTML
+}
+
+func renderUnsubscribePage(c *fiber.Ctx, data fiber.Map) error {
+	var page bytes.Buffer
+	if err := unsubscribePage.Execute(&page, data); err != nil {
+		return apierror.Internal("Failed to render page", err)
+	}
+	c.Type("html", "utf-8")
+	return c.Status(fiber.StatusOK).Send(page.Bytes())
+}
+
+// UnsubscribeStatus is where the unsubscribe link of an email leads. It changes nothing:
+// it returns what the token receives, and browsers get a form that POSTs to Unsubscribe.
+func UnsubscribeStatus(c *fiber.Ctx) error {
+	preferences, err := notifications.ByToken(sql.DB.WithContext(c.UserContext()), c.Query("token"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apierror.NotFound("Unknown unsubscribe token")
+		}
+		return apierror.Internal("Failed to fetch preferences", err)
+	}
+	state := UnsubscribeState{
+		EmailStatusChanges: preferences.EmailStatusChanges,
+		EmailReplies:       preferences.EmailReplies,
+		Digest:             preferences.Digest,
+	}
+	state.Subscribed = state.EmailStatusChanges || state.EmailReplies || state.Digest != models.DigestOff
+
+	if wantsHTML(c) {
+		return renderUnsubscribePage(c, fiber.Map{
+			"Subscribed": state.Subscribed,
+			"Action":     c.OriginalURL(),
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    state,
+	})
+}
+
+// Unsubscribe turns off every email for the token, from the confirmation form or as a
+// one-click unsubscribe from mail clients (RFC 8058).
+func Unsubscribe(c *fiber.Ctx) error {
+	if err := notifications.Unsubscribe(sql.DB.WithContext(c.UserContext()), c.Query("token")); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apierror.NotFound("Unknown unsubscribe token")
+		}
+		return apierror.Internal("Failed to unsubscribe", err)
+	}
+
+	if wantsHTML(c) {
+		return renderUnsubscribePage(c, fiber.Map{"Done": true})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Unsubscribed from all emails",
+	})
+}
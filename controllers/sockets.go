@@ -0,0 +1,275 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"feedback-io.backend/apierror"
+	"feedback-io.backend/auth"
+	sql "feedback-io.backend/config"
+	"feedback-io.backend/events"
+	"feedback-io.backend/logger"
+	"feedback-io.backend/ratelimit"
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// socketTicketTTL only has to cover the time between fetching a ticket and connecting.
+	socketTicketTTL = time.Minute
+	// socketAuthTimeout is how long a new connection may take to send its auth message.
+	socketAuthTimeout = 10 * time.Second
+	// socketPingInterval must stay below socketPongWait so a healthy client never times out.
+	socketPingInterval = 30 * time.Second
+	socketPongWait     = 60 * time.Second
+	socketWriteWait    = 10 * time.Second
+)
+
+// socketMessage is the envelope of every message in both directions, discriminated by Type.
+//
+// Client: {"type":"auth","ticket":"..."}, {"type":"vote","vote":"up|down"}.
+// Server: ready, votes, comment, voted and error.
+type socketMessage struct {
+	Type         string `json:"type"`
+	Ticket       string `json:"ticket,omitempty"`
+	Vote         string `json:"vote,omitempty"`
+	SuggestionId uint   `json:"suggestion_id,omitempty"`
+	Votes        *int   `json:"votes,omitempty"`
+	Comment      any    `json:"comment,omitempty"`
+	Code         string `json:"code,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// SocketTicketResponse is the data of SocketTicket.
+type SocketTicketResponse struct {
+	Ticket    string `json:"ticket"`
+	ExpiresIn int    `json:"expires_in"`
+}
+
+// SocketTicket issues a short lived ticket for the WebSocket auth handshake, since
+// browsers can't set the Authorization header on WebSocket requests.
+func SocketTicket(c *fiber.Ctx) error {
+	userID, _ := auth.UserID(c)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data": SocketTicketResponse{
+			Ticket:    auth.IssueTicket(userID, socketTicketTTL),
+			ExpiresIn: int(socketTicketTTL.Seconds()),
+		},
+	})
+}
+
+// RequireUpgrade rejects plain HTTP requests to a WebSocket endpoint with 426.
+func RequireUpgrade(c *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(c) {
+		return fiber.NewError(fiber.StatusUpgradeRequired, "WebSocket upgrade required")
+	}
+	return c.Next()
+}
+
+// SuggestionSocket pushes the vote count and new comments of one suggestion and accepts
+// votes, which go through the same logic as VoteSuggestion. The first message must be
+// an auth message carrying a ticket from SocketTicket. votePolicy is the per-user vote
+// limit of the HTTP endpoint, so both channels spend the same bucket.
+func SuggestionSocket(store ratelimit.Store, votePolicy ratelimit.Policy) fiber.Handler {
+	return websocket.New(func(conn *websocket.Conn) {
+		socket := &suggestionSocket{conn: conn, store: store, votePolicy: votePolicy}
+		socket.serve()
+	})
+}
+
+type suggestionSocket struct {
+	conn       *websocket.Conn
+	store      ratelimit.Store
+	votePolicy ratelimit.Policy
+
+	// writeMu serializes writes from the read loop and the push loop
+	writeMu sync.Mutex
+	userID  uint
+}
+
+func (s *suggestionSocket) serve() {
+	defer s.conn.Close()
+
+	id, err := strconv.Atoi(s.conn.Params("id"))
+	if err != nil {
+		s.fail(apierror.BadRequest("Invalid suggestion ID"))
+		return
+	}
+
+	log := logger.FromContext(context.Background()).With("suggestion_id", id)
+
+	if err := s.authenticate(); err != nil {
+		s.fail(err)
+		return
+	}
+	log = log.With("user_id", s.userID)
+
+	suggestion, err := findSuggestion(sql.DB, id)
+	if err != nil {
+		s.fail(err)
+		return
+	}
+
+	// subscribe before reporting the count so no vote falls in between
+	_, sub := events.Default.Subscribe(events.Filter{SuggestionId: suggestion.Id}, 0)
+	defer sub.Close()
+
+	votes := suggestion.Votes
+	if err := s.send(socketMessage{Type: "ready", SuggestionId: suggestion.Id, Votes: &votes}); err != nil {
+		return
+	}
+
+	s.conn.SetReadDeadline(time.Now().Add(socketPongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(socketPongWait))
+	})
+
+	done := make(chan struct{})
+	defer close(done)
+	go s.push(sub, done)
+
+	for {
+		var message socketMessage
+		if err := s.conn.ReadJSON(&message); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Debug("suggestion socket closed", "error", err)
+			}
+			return
+		}
+
+		switch message.Type {
+		case "vote":
+			if err := s.vote(id, message.Vote); err != nil {
+				if apiErr := apierror.From(err); apiErr.Status >= fiber.StatusInternalServerError {
+					log.Error("socket vote failed", "error", err)
+				}
+				if s.sendError(err) != nil {
+					return
+				}
+			}
+		default:
+			if s.sendError(apierror.BadRequest("Unknown message type")) != nil {
+				return
+			}
+		}
+	}
+}
+
+// authenticate waits for the auth message and verifies its ticket.
+func (s *suggestionSocket) authenticate() error {
+	s.conn.SetReadDeadline(time.Now().Add(socketAuthTimeout))
+
+	var message socketMessage
+	if err := s.conn.ReadJSON(&message); err != nil || message.Type != "auth" {
+		return apierror.Unauthorized("Expected an auth message")
+	}
+	userID, err := auth.VerifyTicket(message.Ticket)
+	if err != nil {
+		return apierror.Unauthorized("Invalid or expired ticket")
+	}
+	s.userID = userID
+	return nil
+}
+
+func (s *suggestionSocket) vote(id int, vote string) error {
+	key := s.votePolicy.Name + ":user:" + strconv.FormatUint(uint64(s.userID), 10)
+	// fail open like the HTTP limiter when the store is unavailable
+	if result, err := s.store.Take(context.Background(), key, s.votePolicy); err == nil && !result.Allowed {
+		return apierror.TooManyRequests("Rate limit exceeded, retry later")
+	}
+
+	suggestion, err := castVote(context.Background(), id, s.userID, vote)
+	if err != nil {
+		return err
+	}
+	// the broadcast "votes" message follows; the ack tells this client its own vote landed
+	votes := suggestion.Votes
+	return s.send(socketMessage{Type: "voted", Vote: vote, Votes: &votes})
+}
+
+// push forwards events and pings until the subscription or the connection ends.
+func (s *suggestionSocket) push(sub *events.Subscription, done <-chan struct{}) {
+	ping := time.NewTicker(socketPingInterval)
+	defer ping.Stop()
+
+	for {
+		var err error
+		select {
+		case <-done:
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				// the broker shut down or dropped us; the client reconnects
+				s.writeMu.Lock()
+				s.conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(socketWriteWait))
+				s.writeMu.Unlock()
+				s.conn.Close()
+				return
+			}
+			err = s.forward(event)
+		case <-ping.C:
+			s.writeMu.Lock()
+			err = s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(socketWriteWait))
+			s.writeMu.Unlock()
+		}
+		if err != nil {
+			s.conn.Close()
+			return
+		}
+	}
+}
+
+func (s *suggestionSocket) forward(event events.Event) error {
+	switch event.Type {
+	case events.SuggestionVoted:
+		data, ok := event.Data.(fiber.Map)
+		if !ok {
+			return nil
+		}
+		votes, ok := data["votes"].(int)
+		if !ok {
+			return nil
+		}
+		return s.send(socketMessage{Type: "votes", SuggestionId: event.SuggestionId, Votes: &votes})
+	case events.CommentCreated:
+		return s.send(socketMessage{Type: "comment", SuggestionId: event.SuggestionId, Comment: event.Data})
+	}
+	return nil
+}
+
+func (s *suggestionSocket) send(message socketMessage) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.conn.SetWriteDeadline(time.Now().Add(socketWriteWait))
+	return s.conn.WriteMessage(websocket.TextMessage, payload)
+}
+
+func (s *suggestionSocket) sendError(err error) error {
+	apiErr := apierror.From(err)
+	message := apiErr.Message
+	if apiErr.Status >= fiber.StatusInternalServerError {
+		message = "Internal server error"
+	}
+	return s.send(socketMessage{Type: "error", Code: apiErr.Code, Error: message})
+}
+
+// fail reports err and closes the connection with a policy violation.
+func (s *suggestionSocket) fail(err error) {
+	s.sendError(err)
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.ClosePolicyViolation, apierror.From(err).Code), time.Now().Add(socketWriteWait))
+}
@@ -1,14 +1,29 @@
 package controllers
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 	"time"
 
+	"feedback-io.backend/apierror"
+	"feedback-io.backend/audit"
+	"feedback-io.backend/auth"
+	"feedback-io.backend/cache"
 	sql "feedback-io.backend/config"
+	"feedback-io.backend/contentcheck"
+	"feedback-io.backend/events"
+	"feedback-io.backend/logger"
+	"feedback-io.backend/metrics"
 	"feedback-io.backend/models"
+	"feedback-io.backend/moderation"
+	"feedback-io.backend/notifications"
+	"feedback-io.backend/suggestions"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -16,84 +31,134 @@ import (
 func GetSuggestions(c *fiber.Ctx) error {
 
 	var suggestions []models.Suggestion
+	db := sql.DB.WithContext(c.UserContext())
 
 	offset, err_offset := strconv.Atoi(c.Query("offset", "0"))
 	limit, err_limit := strconv.Atoi(c.Query("limit", "10"))
 	var count int64
 
 	if err_offset != nil || err_limit != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error":   "Invalid offset parameter or limit parameter",
-		})
+		return apierror.BadRequest("Invalid offset parameter or limit parameter")
+	}
+
+	category, err := strconv.Atoi(c.Query("category", "0"))
+	if err != nil {
+		return apierror.BadRequest("Invalid category ID format")
+	}
+
+	sort := c.Query("sort", SortNewest)
+	order, ok := suggestionOrders[sort]
+	if !ok {
+		return apierror.Validation("Invalid sort parameter", fiber.Map{"sort": SortOptions})
+	}
+
+	// Serve from cache when the same (normalized) listing was computed since the last change
+	cacheKey := fmt.Sprintf("%slist:category=%d&limit=%d&offset=%d&sort=%s", cache.SuggestionsPrefix, category, limit, offset, sort)
+	if cached, ok := cache.Get(c.UserContext(), cacheKey); ok {
+		var listing cachedListing
+		if err := json.Unmarshal(cached, &listing); err == nil {
+			return sendListing(c, listing)
+		}
 	}
 
 	// Get all suggestions
 	// sql.DB.Model(&models.Suggestion{}).Limit(limit).Offset(offset).Find(&suggestions).Count(&count)
 	// First get the total count
-	if err := sql.DB.Model(&suggestions).Count(&count).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error":   "Failed to fetch suggestions count",
-		})
-	}
-
-	category, err := strconv.Atoi(c.Query("category", "0"))
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error":   "Invalid category ID format",
-		})
+	if err := db.Model(&suggestions).Scopes(models.Visible).Count(&count).Error; err != nil {
+		return apierror.Internal("Failed to fetch suggestions count", err)
 	}
 
-	query := sql.DB
+	query := db.Scopes(models.Visible)
 	if category != 0 {
 		query = query.Where("category_id = ?", category)
 	}
 
 	// Then get the paginated results
 	if err := query.
-		Order("created_at DESC").
+		Order(order).
 		Limit(limit).
 		Offset(offset).
 		Find(&suggestions).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error":   "Failed to fetch suggestions",
-		})
+		return apierror.Internal("Failed to fetch suggestions", err)
 	}
-	return c.Status(200).JSON(fiber.Map{
+
+	body, err := json.Marshal(fiber.Map{
 		"success": true,
 		"count":   count,
 		"data":    suggestions,
 	})
+	if err != nil {
+		return apierror.Internal("Failed to encode suggestions", err)
+	}
+
+	listing := cachedListing{Body: body}
+	for _, suggestion := range suggestions {
+		if suggestion.UpdatedAt.After(listing.LastModified) {
+			listing.LastModified = suggestion.UpdatedAt.Time
+		}
+	}
+	if encoded, err := json.Marshal(listing); err == nil {
+		cache.Set(c.UserContext(), cacheKey, encoded)
+	}
+
+	return sendListing(c, listing)
+}
+
+// Orders of GetSuggestions (?sort=).
+const (
+	SortNewest   = "newest"
+	SortTrending = "trending"
+)
+
+// SortOptions lists the accepted ?sort= values of GetSuggestions.
+var SortOptions = []string{SortNewest, SortTrending}
+
+var suggestionOrders = map[string]string{
+	SortNewest:   "created_at DESC",
+	SortTrending: "trending_score DESC, created_at DESC",
+}
+
+// cachedListing is the cache entry of a GetSuggestions response.
+type cachedListing struct {
+	Body         json.RawMessage `json:"body"`
+	LastModified time.Time       `json:"last_modified"`
+}
+
+func sendListing(c *fiber.Ctx, listing cachedListing) error {
+	if cache.NotModified(c, cache.ETag(listing.Body), listing.LastModified) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Status(fiber.StatusOK).Send(listing.Body)
 }
 
 func GetSuggestion(c *fiber.Ctx) error {
 
 	id, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error":   "Invalid suggestion ID format",
-		})
+		return apierror.BadRequest("Invalid suggestion ID format")
 	}
 
 	var suggestion models.Suggestion
-	if err := sql.DB.First(&suggestion, &id).Error; err != nil {
+	if err := sql.DB.WithContext(c.UserContext()).Scopes(models.Visible).First(&suggestion, &id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"success": false,
-				"error":   "Suggestion not found",
-			})
+			return apierror.NotFound("Suggestion not found")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error":   "Failed to fetch suggestion",
-		})
+		return apierror.Internal("Failed to fetch suggestion", err)
+	}
+
+	// updated_at has second precision, so the vote count is part of the tag too
+	etag := cache.ETag(
+		[]byte(strconv.FormatUint(uint64(suggestion.Id), 10)),
+		[]byte(suggestion.UpdatedAt.Format(time.RFC3339Nano)),
+		[]byte(strconv.Itoa(suggestion.Votes)),
+		[]byte(suggestion.Status),
+	)
+	if cache.NotModified(c, etag, suggestion.UpdatedAt.Time) {
+		return c.SendStatus(fiber.StatusNotModified)
 	}
 
-	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"success": true,
 		"data":    suggestion,
 	})
@@ -102,125 +167,208 @@ func GetSuggestion(c *fiber.Ctx) error {
 func VoteSuggestion(c *fiber.Ctx) error {
 	id, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error":   "Invalid suggestion ID",
-		})
+		return apierror.BadRequest("Invalid suggestion ID")
+	}
+
+	// copied: the vote outlives the request in metrics labels and published events
+	vote := utils.CopyString(c.Query("vote", "up"))
+	userID, _ := auth.UserID(c)
+	suggestion, err := castVote(c.UserContext(), id, userID, vote)
+	if err != nil {
+		return err
 	}
 
-	vote := c.Query("vote", "up")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    suggestion,
+	})
+}
+
+// castVote applies an "up" or "down" vote by userID (0 when anonymous) and returns the
+// updated suggestion. It is shared by the HTTP endpoint and the suggestion WebSocket.
+func castVote(ctx context.Context, id int, userID uint, vote string) (models.Suggestion, error) {
+	var suggestion models.Suggestion
+
 	if vote != "up" && vote != "down" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error":   "Invalid vote parameter: must be 'up' or 'down'",
-		})
+		return suggestion, apierror.BadRequest("Invalid vote parameter: must be 'up' or 'down'")
 	}
 
-	// Start transaction
-	tx := sql.DB.Begin()
-	committed := false
+	voteChange := 1
+	if vote == "down" {
+		voteChange = -1
+	}
 
-	defer func() {
-		if r := recover(); r != nil {
-			if !committed {
-				tx.Rollback()
+	db := sql.DB.WithContext(ctx)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Use locking to prevent concurrent votes
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(models.Visible).First(&suggestion, id).Error; err != nil {
+			logger.FromContext(ctx).Error("failed to lock suggestion for vote", "suggestion_id", id, "error", err)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apierror.NotFound("Suggestion not found")
 			}
-			panic(r)
-		} else if tx.Error != nil && !committed {
-			tx.Rollback()
+			return apierror.Internal("Failed to fetch suggestion", err)
 		}
-	}()
 
-	if tx.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error":   "Failed to start transaction",
-		})
+		if err := tx.Model(&suggestion).
+			Where("id = ?", id).
+			Update("votes", gorm.Expr("votes + ?", voteChange)).Error; err != nil {
+			return apierror.Internal("Failed to update votes", err)
+		}
+
+		if err := tx.Create(&models.Vote{SuggestionId: suggestion.Id, UserId: userID, Value: voteChange}).Error; err != nil {
+			return apierror.Internal("Failed to record vote", err)
+		}
+		return nil
+	})
+	if err != nil {
+		var apiErr *apierror.Error
+		if errors.As(err, &apiErr) {
+			return suggestion, apiErr
+		}
+		return suggestion, apierror.Internal("Failed to commit transaction", err)
+	}
+	metrics.VoteCast(vote)
+	cache.Invalidate(ctx, cache.SuggestionsPrefix)
+	// the row was locked, so the new count is exact
+	events.Publish(events.SuggestionVoted, suggestion.Id, suggestion.CategoryId, fiber.Map{
+		"suggestion_id": suggestion.Id,
+		"vote":          vote,
+		"votes":         suggestion.Votes + voteChange,
+	})
+	notifications.Follow(ctx, userID, suggestion.Id, models.SubscriptionVoter)
+
+	// Fetch updated suggestion
+	if err := db.First(&suggestion, id).Error; err != nil {
+		return suggestion, apierror.Internal("Failed to fetch updated suggestion", err)
 	}
+	return suggestion, nil
+}
 
-	// Use locking to prevent concurrent votes
+// findSuggestion loads a suggestion, reporting a missing or hidden one as 404.
+func findSuggestion(db *gorm.DB, id int) (models.Suggestion, error) {
 	var suggestion models.Suggestion
-	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&suggestion, id).Error; err != nil {
-		fmt.Printf("Error: %v\n", err)
-		tx.Rollback()
+	if err := db.Scopes(models.Visible).First(&suggestion, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"success": false,
-				"error":   "Suggestion not found",
-			})
+			return suggestion, apierror.NotFound("Suggestion not found")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error":   "Failed to fetch suggestion",
-		})
+		return suggestion, apierror.Internal("Failed to fetch suggestion", err)
 	}
+	return suggestion, nil
+}
 
-	voteChange := 1
-	if vote == "down" {
-		voteChange = -1
+type UpdateStatusInput struct {
+	Status string `json:"status"`
+}
+
+func UpdateSuggestionStatus(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return apierror.BadRequest("Invalid suggestion ID")
 	}
 
-	if err := tx.Model(&suggestion).
-		Where("id = ?", id).
-		Update("votes", gorm.Expr("votes + ?", voteChange)).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error":   "Failed to update votes",
-		})
+	var input UpdateStatusInput
+	if err := c.BodyParser(&input); err != nil {
+		return apierror.BadRequest("Failed to parse request body")
+	}
+	if !slices.Contains(models.Statuses, input.Status) {
+		return apierror.Validation("Invalid status", fiber.Map{"status": models.Statuses})
 	}
 
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error":   "Failed to commit transaction",
-		})
+	db := sql.DB.WithContext(c.UserContext())
+
+	suggestion, err := findSuggestion(db, id)
+	if err != nil {
+		return err
 	}
 
-	// Fetch updated suggestion
-	if err := sql.DB.First(&suggestion, id).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error":   "Failed to fetch updated suggestion",
+	if suggestion.Status == input.Status {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"success": true,
+			"data":    suggestion,
 		})
 	}
-	committed = true
+
+	previous, err := suggestions.SetStatus(c.UserContext(), db, audit.ActorOf(c), &suggestion, input.Status)
+	if err != nil {
+		return apierror.Internal("Failed to update status", err)
+	}
+	events.Publish(events.SuggestionStatusChanged, suggestion.Id, suggestion.CategoryId, fiber.Map{
+		"suggestion_id": suggestion.Id,
+		"from":          previous,
+		"to":            suggestion.Status,
+	})
+	actorID, _ := auth.UserID(c)
+	notifications.Notify(c.UserContext(), suggestion.Id, actorID, models.NotificationStatusChanged, fiber.Map{
+		"suggestion_id":    suggestion.Id,
+		"suggestion_title": suggestion.Title,
+		"from":             previous,
+		"to":               suggestion.Status,
+	})
+	notifications.EmailStatusChange(c.UserContext(), suggestion, previous, actorID)
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"success": true,
 		"data":    suggestion,
 	})
 }
 
-func CreateSuggestion(c *fiber.Ctx) error {
-	type CreateSuggestionInput struct {
-		Title      string `json:"title"`
-		Content    string `json:"content"`
-		CategoryId uint   `json:"category_id"`
-		UserId     uint   `json:"user_id"`
-	}
+type CreateSuggestionInput struct {
+	Title      string `json:"title"`
+	Content    string `json:"content"`
+	CategoryId uint   `json:"category_id"`
+}
 
+// CreateSuggestion stores a new suggestion by the signed-in user, or anonymously (user 0).
+// Content flagged by the content checks is stored hidden and queued for moderation,
+// without notifying anyone.
+func CreateSuggestion(c *fiber.Ctx) error {
 	var input CreateSuggestionInput
 	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error":   "Failed to parse request body",
-		})
+		return apierror.BadRequest("Failed to parse request body")
 	}
 
+	userID, _ := auth.UserID(c)
 	suggestion := models.Suggestion{
 		Title:      input.Title,
 		Content:    input.Content,
 		CategoryId: input.CategoryId,
-		UserId:     input.UserId,
-		Status:     "suggestion",
+		UserId:     userID,
+		Status:     models.StatusSuggestion,
+	}
+
+	verdict := contentcheck.Run(c.UserContext(), contentcheck.Content{
+		Kind:   models.ReportSuggestion,
+		UserId: suggestion.UserId,
+		Ip:     c.IP(),
+		Title:  input.Title,
+		Body:   input.Content,
+	})
+	if verdict.Flagged {
+		suggestion.HiddenAt = &models.DateTime{Time: time.Now()}
 	}
 
-	if err := sql.DB.Create(&suggestion).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error":   "Failed to create suggestion",
+	if err := sql.DB.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&suggestion).Error; err != nil {
+			return err
+		}
+		if verdict.Flagged {
+			return moderation.Hold(tx, models.ReportSuggestion, suggestion.Id, verdict.Reasons)
+		}
+		return nil
+	}); err != nil {
+		return apierror.FromDB(err, "Failed to create suggestion")
+	}
+	metrics.SuggestionCreated()
+	notifications.Follow(c.UserContext(), suggestion.UserId, suggestion.Id, models.SubscriptionAuthor)
+	if verdict.Flagged {
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"success": true,
+			"message": "Suggestion is awaiting moderation",
+			"data":    suggestion,
 		})
 	}
+	cache.Invalidate(c.UserContext(), cache.SuggestionsPrefix)
+	events.Publish(events.SuggestionCreated, suggestion.Id, suggestion.CategoryId, suggestion)
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"success": true,
@@ -231,62 +379,20 @@ func CreateSuggestion(c *fiber.Ctx) error {
 func DeleteSuggestion(c *fiber.Ctx) error {
 	id, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error":   "Invalid suggestion ID",
-		})
-	}
-
-	tx := sql.DB.Begin()
-
-	var suggestions models.Suggestion // before we delete suggestion, we need to delete Replies, Comments, and then Suggestion
-
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-			panic(r)
-		} else if tx.Error != nil {
-			tx.Rollback()
-		}
-	}()
-
-	if err := tx.First(&suggestions, id).Error; err != nil {
-
-		tx.Rollback()
-		return err
+		return apierror.BadRequest("Invalid suggestion ID")
 	}
 
-	// delete all replies
-	if suggestions.Comments != nil {
-		for _, comment := range *suggestions.Comments {
-			if err := tx.Model(&models.Reply{}).Where("comment_id = ?", comment.Id).Update("deleted_at", time.Now()).Error; err != nil {
-				tx.Rollback()
-				return err
-			}
+	suggestion, err := suggestions.Delete(c.UserContext(), sql.DB.WithContext(c.UserContext()), audit.ActorOf(c), uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apierror.NotFound("Suggestion not found")
 		}
-	}
-
-	if err := tx.Model(&models.Comment{}).Where("suggestion_id = ?", id).Update("deleted_at", time.Now()).Error; err != nil {
-		tx.Rollback()
-		return err
-	}
-
-	if err := tx.Model(&models.Suggestion{}).Where("id = ?", id).Update("deleted_at", time.Now()).Error; err != nil {
-		tx.Rollback()
-		return err
-	}
-
-	if err := tx.Commit().Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error":   "Failed to commit transaction",
-		})
+		return apierror.Internal("Failed to delete suggestion", err)
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"success": true,
 		"message": "Suggestion deleted successfully",
-		"data":    suggestions,
+		"data":    suggestion,
 	})
-
 }
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"errors"
+	"slices"
+
+	"feedback-io.backend/apierror"
+	"feedback-io.backend/audit"
+	sql "feedback-io.backend/config"
+	"feedback-io.backend/models"
+	"feedback-io.backend/scheduler"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// GetScheduledTasks lists the enabled scheduled tasks with their last and next run.
+func GetScheduledTasks(c *fiber.Ctx) error {
+	tasks := []models.ScheduledTask{}
+	if names := scheduler.Tasks(); len(names) > 0 {
+		if err := sql.DB.WithContext(c.UserContext()).
+			Where("name IN ?", names).
+			Order("name").
+			Find(&tasks).Error; err != nil {
+			return apierror.Internal("Failed to fetch scheduled tasks", err)
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"count":   len(tasks),
+		"data":    tasks,
+	})
+}
+
+// RunScheduledTask makes a task due now; one of the replicas runs it within seconds.
+func RunScheduledTask(c *fiber.Ctx) error {
+	name := c.Params("name")
+	if !slices.Contains(scheduler.Tasks(), name) {
+		return apierror.NotFound("Scheduled task not found")
+	}
+
+	task, err := scheduler.RunNow(sql.DB.WithContext(c.UserContext()), audit.ActorOf(c), name)
+	switch {
+	case errors.Is(err, scheduler.ErrRunning):
+		return apierror.Conflict("Task is already running")
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return apierror.NotFound("Scheduled task not found")
+	case err != nil:
+		return apierror.Internal("Failed to schedule task", err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"success": true,
+		"data":    task,
+	})
+}
@@ -0,0 +1,257 @@
+package controllers
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"feedback-io.backend/apierror"
+	"feedback-io.backend/audit"
+	sql "feedback-io.backend/config"
+	"feedback-io.backend/events"
+	"feedback-io.backend/models"
+	"feedback-io.backend/webhooks"
+	"github.com/gofiber/fiber/v2"
+)
+
+type CreateWebhookInput struct {
+	Url         string   `json:"url"`
+	Events      []string `json:"events"`
+	Description string   `json:"description"`
+	// Secret is generated when empty
+	Secret string `json:"secret"`
+}
+
+// UpdateWebhookInput changes the fields that are present. RotateSecret replaces the
+// signing secret and returns the new one.
+type UpdateWebhookInput struct {
+	Url          *string   `json:"url"`
+	Events       *[]string `json:"events"`
+	Description  *string   `json:"description"`
+	Active       *bool     `json:"active"`
+	RotateSecret bool      `json:"rotate_secret"`
+}
+
+// WebhookWithSecret is returned when the secret is created or rotated; it is never shown again.
+type WebhookWithSecret struct {
+	models.Webhook
+	Secret string `json:"secret"`
+}
+
+func GetWebhooks(c *fiber.Ctx) error {
+	var hooks []models.Webhook
+	if err := sql.DB.WithContext(c.UserContext()).Order("id").Find(&hooks).Error; err != nil {
+		return apierror.Internal("Failed to fetch webhooks", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"count":   len(hooks),
+		"data":    hooks,
+	})
+}
+
+func GetWebhook(c *fiber.Ctx) error {
+	hook, err := findWebhook(c)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    hook,
+	})
+}
+
+func CreateWebhook(c *fiber.Ctx) error {
+	var input CreateWebhookInput
+	if err := c.BodyParser(&input); err != nil {
+		return apierror.BadRequest("Failed to parse request body")
+	}
+	if err := validateWebhook(c.UserContext(), input.Url, input.Events); err != nil {
+		return err
+	}
+
+	secret := input.Secret
+	if secret == "" {
+		secret = webhooks.NewSecret()
+	}
+	hook := models.Webhook{
+		Url:         input.Url,
+		Secret:      secret,
+		Events:      input.Events,
+		Description: input.Description,
+		Active:      true,
+	}
+	if err := webhooks.Create(sql.DB.WithContext(c.UserContext()), audit.ActorOf(c), &hook); err != nil {
+		return apierror.FromDB(err, "Failed to create webhook")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    WebhookWithSecret{Webhook: hook, Secret: secret},
+	})
+}
+
+func UpdateWebhook(c *fiber.Ctx) error {
+	hook, err := findWebhook(c)
+	if err != nil {
+		return err
+	}
+
+	var input UpdateWebhookInput
+	if err := c.BodyParser(&input); err != nil {
+		return apierror.BadRequest("Failed to parse request body")
+	}
+
+	hookURL, eventTypes := hook.Url, hook.Events
+	if input.Url != nil {
+		hookURL = *input.Url
+	}
+	if input.Events != nil {
+		eventTypes = *input.Events
+	}
+	if err := validateWebhook(c.UserContext(), hookURL, eventTypes); err != nil {
+		return err
+	}
+
+	changes := webhooks.Changes{
+		Url:          input.Url,
+		Events:       input.Events,
+		Description:  input.Description,
+		Active:       input.Active,
+		RotateSecret: input.RotateSecret,
+	}
+	if err := webhooks.Update(sql.DB.WithContext(c.UserContext()), audit.ActorOf(c), &hook, changes); err != nil {
+		return apierror.FromDB(err, "Failed to update webhook")
+	}
+
+	if input.RotateSecret {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"success": true,
+			"data":    WebhookWithSecret{Webhook: hook, Secret: hook.Secret},
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    hook,
+	})
+}
+
+func DeleteWebhook(c *fiber.Ctx) error {
+	hook, err := findWebhook(c)
+	if err != nil {
+		return err
+	}
+
+	if err := webhooks.Delete(sql.DB.WithContext(c.UserContext()), audit.ActorOf(c), hook); err != nil {
+		return apierror.Internal("Failed to delete webhook", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Webhook deleted successfully",
+	})
+}
+
+// GetWebhookDeliveries lists the delivery log of a webhook, newest first.
+// Optional filter: ?status=pending|succeeded|failed.
+func GetWebhookDeliveries(c *fiber.Ctx) error {
+	hook, err := findWebhook(c)
+	if err != nil {
+		return err
+	}
+
+	offset, err_offset := strconv.Atoi(c.Query("offset", "0"))
+	limit, err_limit := strconv.Atoi(c.Query("limit", "20"))
+	if err_offset != nil || err_limit != nil {
+		return apierror.BadRequest("Invalid offset parameter or limit parameter")
+	}
+
+	query := sql.DB.WithContext(c.UserContext()).Model(&models.WebhookDelivery{}).Where("webhook_id = ?", hook.Id)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return apierror.Internal("Failed to fetch deliveries count", err)
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&deliveries).Error; err != nil {
+		return apierror.Internal("Failed to fetch deliveries", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"count":   count,
+		"data":    deliveries,
+	})
+}
+
+// RedeliverWebhookDelivery queues the payload of a logged delivery again.
+func RedeliverWebhookDelivery(c *fiber.Ctx) error {
+	hook, err := findWebhook(c)
+	if err != nil {
+		return err
+	}
+	deliveryID, err := strconv.Atoi(c.Params("delivery"))
+	if err != nil {
+		return apierror.BadRequest("Invalid delivery ID")
+	}
+
+	db := sql.DB.WithContext(c.UserContext())
+
+	var original models.WebhookDelivery
+	if err := db.Where("webhook_id = ?", hook.Id).First(&original, deliveryID).Error; err != nil {
+		return apierror.FromDB(err, "Failed to fetch delivery")
+	}
+
+	delivery, err := webhooks.Redeliver(db, audit.ActorOf(c), original)
+	if err != nil {
+		return apierror.Internal("Failed to queue delivery", err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"success": true,
+		"data":    delivery,
+	})
+}
+
+func findWebhook(c *fiber.Ctx) (models.Webhook, error) {
+	var hook models.Webhook
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return hook, apierror.BadRequest("Invalid webhook ID")
+	}
+	if err := sql.DB.WithContext(c.UserContext()).First(&hook, id).Error; err != nil {
+		return hook, apierror.FromDB(err, "Failed to fetch webhook")
+	}
+	return hook, nil
+}
+
+// validateWebhook requires an absolute http(s) URL of a public host and known event types
+// ("*" for all).
+func validateWebhook(ctx context.Context, rawURL string, eventTypes []string) error {
+	target, err := url.Parse(rawURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return apierror.Validation("Invalid webhook URL", fiber.Map{"url": "absolute http or https URL"})
+	}
+	if err := webhooks.CheckURL(ctx, rawURL); err != nil {
+		return apierror.Validation("Webhook URL must resolve to a public address", fiber.Map{"url": err.Error()})
+	}
+
+	if len(eventTypes) == 0 {
+		return apierror.Validation("At least one event type is required", fiber.Map{"events": "required"})
+	}
+	for _, eventType := range eventTypes {
+		if eventType != models.WebhookAllEvents && !slices.Contains(events.Types, eventType) {
+			allowed := append([]string{models.WebhookAllEvents}, events.Types...)
+			return apierror.Validation("Unknown event type "+eventType, fiber.Map{"events": allowed})
+		}
+	}
+	return nil
+}
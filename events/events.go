@@ -0,0 +1,195 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types published by the controllers.
+const (
+	SuggestionCreated       = "suggestion.created"
+	SuggestionVoted         = "suggestion.voted"
+	SuggestionStatusChanged = "suggestion.status_changed"
+	CommentCreated          = "comment.created"
+)
+
+// Types lists every event type, e.g. to validate webhook subscriptions.
+var Types = []string{SuggestionCreated, SuggestionVoted, SuggestionStatusChanged, CommentCreated}
+
+// Event is one change, published after the database transaction committed. ID is the
+// position in this process's event log, used to resume streams; it restarts with the
+// process and repeats across replicas. UUID identifies the event everywhere, e.g. for
+// webhook receivers to deduplicate on.
+type Event struct {
+	ID           uint64    `json:"id"`
+	UUID         string    `json:"uuid"`
+	Type         string    `json:"type"`
+	SuggestionId uint      `json:"suggestion_id"`
+	CategoryId   uint      `json:"category_id"`
+	Time         time.Time `json:"time"`
+	Data         any       `json:"data"`
+}
+
+// Filter restricts a subscription to one suggestion and/or category. Zero values match everything.
+type Filter struct {
+	SuggestionId uint
+	CategoryId   uint
+}
+
+func (f Filter) Match(e Event) bool {
+	if f.SuggestionId != 0 && f.SuggestionId != e.SuggestionId {
+		return false
+	}
+	if f.CategoryId != 0 && f.CategoryId != e.CategoryId {
+		return false
+	}
+	return true
+}
+
+// Subscription receives matching events on C. C is closed when the subscriber fell too far
+// behind or the broker shut down; the client is expected to reconnect with Last-Event-ID.
+type Subscription struct {
+	C      <-chan Event
+	ch     chan Event
+	filter Filter
+	broker *Broker
+	once   sync.Once
+}
+
+func (s *Subscription) Close() {
+	s.broker.unsubscribe(s)
+}
+
+// Broker fans events out to subscribers and keeps the last events in a bounded log
+// so reconnecting clients can resume.
+type Broker struct {
+	mu          sync.Mutex
+	log         []Event
+	size        int
+	nextID      uint64
+	subscribers map[*Subscription]struct{}
+	closed      bool
+}
+
+// subscriberBuffer is how many events a subscriber may lag behind before it is dropped.
+const subscriberBuffer = 64
+
+func NewBroker(size int) *Broker {
+	return &Broker{
+		size:        size,
+		nextID:      1,
+		subscribers: map[*Subscription]struct{}{},
+	}
+}
+
+// Publish records the event and delivers it to matching subscribers without blocking.
+func (b *Broker) Publish(eventType string, suggestionID, categoryID uint, data any) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	event := Event{
+		ID:           b.nextID,
+		UUID:         uuid.NewString(),
+		Type:         eventType,
+		SuggestionId: suggestionID,
+		CategoryId:   categoryID,
+		Time:         time.Now().UTC(),
+		Data:         data,
+	}
+	b.nextID++
+
+	b.log = append(b.log, event)
+	if len(b.log) > b.size {
+		b.log = b.log[len(b.log)-b.size:]
+	}
+
+	for sub := range b.subscribers {
+		if !sub.filter.Match(event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			// too slow; it will resume from the log after reconnecting
+			b.drop(sub)
+		}
+	}
+
+	return event
+}
+
+// Backlog is what a subscriber missed since the event it last received.
+type Backlog struct {
+	// Events are the logged events after the requested id that match the filter.
+	Events []Event
+	// Gap is set when some of the missed events are no longer in the log: they were
+	// evicted, or published by a previous process. Events is then incomplete and the
+	// subscriber should reload its state.
+	Gap bool
+	// LastID is the id of the latest published event, 0 when there is none.
+	LastID uint64
+}
+
+// Subscribe registers a subscriber and returns the logged events after lastEventID that
+// match filter.
+func (b *Broker) Subscribe(filter Filter, lastEventID uint64) (Backlog, *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Event, subscriberBuffer)
+	sub := &Subscription{C: ch, ch: ch, filter: filter, broker: b}
+	backlog := Backlog{Events: []Event{}, LastID: b.nextID - 1}
+	if b.closed {
+		close(ch)
+		return backlog, sub
+	}
+	b.subscribers[sub] = struct{}{}
+
+	if lastEventID == 0 {
+		return backlog, sub
+	}
+	oldest := b.nextID // id of the oldest logged event
+	if len(b.log) > 0 {
+		oldest = b.log[0].ID
+	}
+	backlog.Gap = lastEventID > backlog.LastID || lastEventID+1 < oldest
+	for _, event := range b.log {
+		if event.ID > lastEventID && filter.Match(event) {
+			backlog.Events = append(backlog.Events, event)
+		}
+	}
+	return backlog, sub
+}
+
+// Close ends every subscription, letting streaming responses finish during shutdown.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subscribers {
+		b.drop(sub)
+	}
+}
+
+func (b *Broker) unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.drop(sub)
+}
+
+// drop must be called with b.mu held.
+func (b *Broker) drop(sub *Subscription) {
+	delete(b.subscribers, sub)
+	sub.once.Do(func() { close(sub.ch) })
+}
+
+// Default is the process wide broker used by the controllers.
+var Default = NewBroker(1000)
+
+// Publish publishes on the default broker.
+func Publish(eventType string, suggestionID, categoryID uint, data any) Event {
+	return Default.Publish(eventType, suggestionID, categoryID, data)
+}
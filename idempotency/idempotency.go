@@ -0,0 +1,181 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"strconv"
+	"time"
+
+	"feedback-io.backend/apierror"
+	"feedback-io.backend/auth"
+	sql "feedback-io.backend/config"
+	"feedback-io.backend/logger"
+	"feedback-io.backend/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	HeaderKey      = "Idempotency-Key"
+	HeaderReplayed = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+)
+
+// Middleware makes a route safe to retry. The first response to a given Idempotency-Key
+// (per user, or per IP for anonymous callers) is stored and replayed on retries; reusing
+// the key for a different request is rejected with 422. Server errors, rate limited
+// responses and panics are not stored so the client can retry them. A claim is held for
+// IDEMPOTENCY_LOCK_TIMEOUT, after which a retry takes it over.
+func Middleware() fiber.Handler {
+	ttl := sql.GetEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour)
+	lockTimeout := sql.GetEnvDuration("IDEMPOTENCY_LOCK_TIMEOUT", time.Minute)
+
+	return func(c *fiber.Ctx) error {
+		key := c.Get(HeaderKey)
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > maxKeyLength {
+			return apierror.BadRequest("Idempotency-Key must be at most 255 characters")
+		}
+
+		db := sql.DB.WithContext(c.UserContext())
+		scope := scopeOf(c)
+		hash := fingerprint(c)
+		now := time.Now()
+
+		// an expired key can be reused as if it had never been seen
+		if err := db.Where("scope = ? AND idempotency_key = ? AND expires_at <= ?", scope, key, models.DateTime{Time: now}).
+			Delete(&models.IdempotencyKey{}).Error; err != nil {
+			return apierror.Internal("Failed to expire idempotency key", err)
+		}
+
+		var record models.IdempotencyKey
+		err := db.Where("scope = ? AND idempotency_key = ?", scope, key).First(&record).Error
+		switch {
+		case err == nil:
+			if record.StatusCode != 0 || record.RequestHash != hash || record.LockedUntil.After(now) {
+				return replay(c, record, hash)
+			}
+			// the request holding the claim never finished; take it over
+			result := db.Model(&models.IdempotencyKey{}).
+				Where("id = ? AND status_code = 0 AND locked_until <= ?", record.Id, models.DateTime{Time: now}).
+				Update("locked_until", models.DateTime{Time: now.Add(lockTimeout)})
+			if result.Error != nil {
+				return apierror.Internal("Failed to claim idempotency key", result.Error)
+			}
+			if result.RowsAffected == 0 {
+				return apierror.Conflict("A request with this Idempotency-Key is already in progress")
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			// claim the key; the unique index makes concurrent first attempts collide here
+			record = models.IdempotencyKey{
+				Key:         key,
+				Scope:       scope,
+				RequestHash: hash,
+				LockedUntil: models.DateTime{Time: now.Add(lockTimeout)},
+				ExpiresAt:   models.DateTime{Time: now.Add(ttl)},
+			}
+			if err := db.Create(&record).Error; err != nil {
+				if errors.Is(err, gorm.ErrDuplicatedKey) {
+					return apierror.Conflict("A request with this Idempotency-Key is already in progress")
+				}
+				return apierror.Internal("Failed to store idempotency key", err)
+			}
+		default:
+			return apierror.Internal("Failed to look up idempotency key", err)
+		}
+
+		// release the claim unless a response was stored, including when the handler panics
+		stored := false
+		defer func() {
+			if stored {
+				return
+			}
+			if err := db.Delete(&record).Error; err != nil {
+				logger.FromContext(c.UserContext()).Error("failed to release idempotency key", "key", key, "error", err)
+			}
+		}()
+
+		if err := c.Next(); err != nil {
+			// render now so the response can be stored
+			if err := apierror.Handler(c, err); err != nil {
+				return err
+			}
+		}
+
+		status := c.Response().StatusCode()
+		if !storable(status) {
+			return nil
+		}
+
+		if err := db.Model(&record).Updates(map[string]interface{}{
+			"status_code":   status,
+			"content_type":  string(c.Response().Header.ContentType()),
+			"response_body": append([]byte{}, c.Response().Body()...),
+		}).Error; err != nil {
+			logger.FromContext(c.UserContext()).Error("failed to store idempotent response", "key", key, "error", err)
+			return nil
+		}
+		stored = true
+		return nil
+	}
+}
+
+// storable reports whether a response with status is kept for replay. Server errors and
+// rate limited responses aren't, so the client can retry them.
+func storable(status int) bool {
+	return status < fiber.StatusInternalServerError && status != fiber.StatusTooManyRequests
+}
+
+func replay(c *fiber.Ctx, record models.IdempotencyKey, hash string) error {
+	if record.RequestHash != hash {
+		return apierror.New(fiber.StatusUnprocessableEntity, apierror.CodeIdempotencyKey,
+			"Idempotency-Key was already used for a different request")
+	}
+	if record.StatusCode == 0 {
+		return apierror.Conflict("A request with this Idempotency-Key is already in progress")
+	}
+
+	c.Set(HeaderReplayed, "true")
+	if record.ContentType != "" {
+		c.Set(fiber.HeaderContentType, record.ContentType)
+	}
+	return c.Status(record.StatusCode).Send(record.ResponseBody)
+}
+
+// DeleteExpired removes the keys whose TTL has passed and returns how many there were.
+// Middleware already ignores expired keys; this only reclaims their space.
+func DeleteExpired(db *gorm.DB) (int64, error) {
+	result := db.Where("expires_at <= ?", models.DateTime{Time: time.Now()}).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
+
+func scopeOf(c *fiber.Ctx) string {
+	if id, ok := auth.UserID(c); ok {
+		return "user:" + strconv.FormatUint(uint64(id), 10)
+	}
+	return "ip:" + c.IP()
+}
+
+// versionPrefix is the API version mount point, e.g. /api/v1.
+var versionPrefix = regexp.MustCompile(`^/api/v[0-9]+(/|$)`)
+
+// fingerprint identifies the request payload: method, path relative to the API version
+// with the query string, and body. A retry sent to an unversioned alias of the route
+// matches the original request.
+func fingerprint(c *fiber.Ctx) string {
+	path := versionPrefix.ReplaceAllString(c.Path(), "/")
+	h := sha256.New()
+	h.Write([]byte(c.Method()))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{'?'})
+	h.Write(c.Request().URI().QueryString())
+	h.Write([]byte{0})
+	h.Write(c.Body())
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,135 @@
+package idempotency
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"feedback-io.backend/apierror"
+	"feedback-io.backend/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestFingerprint(t *testing.T) {
+	app := fiber.New()
+	app.All("/*", func(c *fiber.Ctx) error {
+		return c.SendString(fingerprint(c))
+	})
+	hash := func(method, target, body string) string {
+		resp, err := app.Test(httptest.NewRequest(method, target, strings.NewReader(body)))
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, target, err)
+		}
+		data, _ := io.ReadAll(resp.Body)
+		return string(data)
+	}
+
+	original := hash("POST", "/api/v1/suggestions?notify=1", `{"title":"a"}`)
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		same   bool
+	}{
+		{"identical retry", "POST", "/api/v1/suggestions?notify=1", `{"title":"a"}`, true},
+		{"unversioned alias", "POST", "/suggestions?notify=1", `{"title":"a"}`, true},
+		{"other version", "POST", "/api/v2/suggestions?notify=1", `{"title":"a"}`, true},
+		{"different body", "POST", "/api/v1/suggestions?notify=1", `{"title":"b"}`, false},
+		{"different query", "POST", "/api/v1/suggestions?notify=0", `{"title":"a"}`, false},
+		{"no query", "POST", "/api/v1/suggestions", `{"title":"a"}`, false},
+		{"different method", "PUT", "/api/v1/suggestions?notify=1", `{"title":"a"}`, false},
+		{"different path", "POST", "/api/v1/suggestion?notify=1", `{"title":"a"}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hash(tt.method, tt.target, tt.body) == original; got != tt.same {
+				t.Errorf("same fingerprint = %v, want %v", got, tt.same)
+			}
+		})
+	}
+}
+
+func TestStorable(t *testing.T) {
+	tests := []struct {
+		status int
+		want   bool
+	}{
+		{fiber.StatusOK, true},
+		{fiber.StatusCreated, true},
+		{fiber.StatusBadRequest, true},
+		{fiber.StatusConflict, true},
+		{fiber.StatusUnprocessableEntity, true},
+		{fiber.StatusTooManyRequests, false},
+		{fiber.StatusInternalServerError, false},
+		{fiber.StatusServiceUnavailable, false},
+	}
+	for _, tt := range tests {
+		if got := storable(tt.status); got != tt.want {
+			t.Errorf("storable(%d) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestReplay(t *testing.T) {
+	const hash = "abc"
+	tests := []struct {
+		name         string
+		record       models.IdempotencyKey
+		wantStatus   int
+		wantReplayed bool
+		wantBody     string
+	}{
+		{
+			name:         "stored response",
+			record:       models.IdempotencyKey{RequestHash: hash, StatusCode: fiber.StatusCreated, ContentType: "application/json", ResponseBody: []byte(`{"id":1}`)},
+			wantStatus:   fiber.StatusCreated,
+			wantReplayed: true,
+			wantBody:     `{"id":1}`,
+		},
+		{
+			name:       "different request",
+			record:     models.IdempotencyKey{RequestHash: "other", StatusCode: fiber.StatusCreated},
+			wantStatus: fiber.StatusUnprocessableEntity,
+		},
+		{
+			name:       "different request still in progress",
+			record:     models.IdempotencyKey{RequestHash: "other"},
+			wantStatus: fiber.StatusUnprocessableEntity,
+		},
+		{
+			name:       "in progress",
+			record:     models.IdempotencyKey{RequestHash: hash},
+			wantStatus: fiber.StatusConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New(fiber.Config{ErrorHandler: apierror.Handler})
+			app.Post("/", func(c *fiber.Ctx) error {
+				return replay(c, tt.record, hash)
+			})
+
+			resp, err := app.Test(httptest.NewRequest("POST", "/", nil))
+			if err != nil {
+				t.Fatalf("Test: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := resp.Header.Get(HeaderReplayed) == "true"; got != tt.wantReplayed {
+				t.Errorf("replayed = %v, want %v", got, tt.wantReplayed)
+			}
+			if tt.wantBody != "" {
+				body, _ := io.ReadAll(resp.Body)
+				if string(body) != tt.wantBody {
+					t.Errorf("body = %s, want %s", body, tt.wantBody)
+				}
+				if got := resp.Header.Get(fiber.HeaderContentType); got != tt.record.ContentType {
+					t.Errorf("content type = %q, want %q", got, tt.record.ContentType)
+				}
+			}
+		})
+	}
+}
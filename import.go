@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"feedback-io.backend/audit"
+	"feedback-io.backend/cache"
+	database "feedback-io.backend/config"
+	"feedback-io.backend/importer"
+)
+
+// runImport implements `import [-format csv|json] [-dry-run] FILE`, the command line
+// counterpart of POST /api/v1/import for files too large for a request. It prints the
+// report as JSON and returns the exit status: 1 when the import failed or a row is
+// invalid, 2 on usage errors.
+func runImport(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := flags.String("format", "", "input format, csv or json (default: from the file extension)")
+	dryRun := flags.Bool("dry-run", false, "check and roll back instead of committing")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: import [-format csv|json] [-dry-run] FILE")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	path := flags.Arg(0)
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer file.Close()
+
+	database.ConnectDatabase()
+	defer database.CloseDatabase()
+	cache.Setup()
+
+	ctx := context.Background()
+	report, err := importer.Import(ctx, database.DB, *format, file, importer.Options{DryRun: *dryRun, Actor: audit.System})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "import failed:", err)
+		return 1
+	}
+	if report.Committed {
+		cache.Invalidate(ctx, cache.SuggestionsPrefix)
+	}
+
+	out := json.NewEncoder(os.Stdout)
+	out.SetIndent("", "  ")
+	out.Encode(report)
+
+	if len(report.Errors) > 0 {
+		fmt.Fprintf(os.Stderr, "%d errors; nothing was imported\n", len(report.Errors))
+		return 1
+	}
+	return 0
+}
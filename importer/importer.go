@@ -0,0 +1,343 @@
+// Package importer loads suggestions and their comments exported from another feedback
+// board. Categories and users are matched by name and email, and created when missing.
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"feedback-io.backend/audit"
+	"feedback-io.backend/models"
+	"gorm.io/gorm"
+)
+
+// Record is one suggestion to import.
+type Record struct {
+	// Row is the line of the record in a CSV input, or its position (from 1) in a JSON array.
+	Row         int       `json:"-"`
+	Title       string    `json:"title"`
+	Content     string    `json:"content"`
+	Category    string    `json:"category"`
+	AuthorEmail string    `json:"author_email"`
+	Votes       int       `json:"votes"`
+	Status      string    `json:"status"`
+	CreatedAt   string    `json:"created_at"`
+	Comments    []Comment `json:"comments"`
+}
+
+type Comment struct {
+	AuthorEmail string `json:"author_email"`
+	Content     string `json:"content"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// RowError is a problem with one record. Field is empty when it concerns the whole record.
+type RowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// Report is the outcome of an import. Nothing is written unless Committed is true, which
+// requires every record to be valid and DryRun to be off.
+type Report struct {
+	DryRun            bool       `json:"dry_run"`
+	Committed         bool       `json:"committed"`
+	Rows              int        `json:"rows"`
+	Suggestions       int        `json:"suggestions"`
+	Comments          int        `json:"comments"`
+	CategoriesCreated []string   `json:"categories_created"`
+	UsersCreated      []string   `json:"users_created"`
+	Errors            []RowError `json:"errors"`
+}
+
+type Options struct {
+	// DryRun runs the whole import, then rolls it back.
+	DryRun bool
+	// MaxRows rejects larger inputs; 0 means no limit.
+	MaxRows int
+	// Actor is recorded in the audit log as the author of the import.
+	Actor audit.Actor
+}
+
+// ErrTooManyRows is returned when the input has more than Options.MaxRows records.
+var ErrTooManyRows = errors.New("too many rows")
+
+// errDryRun rolls back the transaction of a dry run.
+var errDryRun = errors.New("dry run")
+
+// timeLayouts are the accepted created_at formats, tried in order.
+var timeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"}
+
+// Import reads records in format (CSV or JSON) and writes them in one transaction: either
+// every record is imported or none is. Invalid records are listed in Report.Errors. The
+// error is only set when the input is unreadable (ErrMalformed, ErrTooManyRows) or the
+// database fails.
+//
+// Votes set the vote total of a suggestion without entries in the vote ledger, since the
+// voters of another board are unknown. Imported users have no password.
+func Import(ctx context.Context, db *gorm.DB, format string, r io.Reader, opts Options) (Report, error) {
+	report := Report{DryRun: opts.DryRun, CategoriesCreated: []string{}, UsersCreated: []string{}, Errors: []RowError{}}
+
+	records, rowErrors, err := parse(format, r)
+	if err != nil {
+		return report, err
+	}
+	report.Rows = len(records)
+	if opts.MaxRows > 0 && len(records) > opts.MaxRows {
+		return report, fmt.Errorf("%w: %d, at most %d", ErrTooManyRows, len(records), opts.MaxRows)
+	}
+
+	report.Errors = append(report.Errors, rowErrors...)
+	for _, record := range records {
+		report.Errors = append(report.Errors, validate(record)...)
+	}
+	slices.SortStableFunc(report.Errors, func(a, b RowError) int { return a.Row - b.Row })
+	if len(report.Errors) > 0 {
+		return report, nil
+	}
+
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		w := &writer{tx: tx, report: &report, categories: map[string]uint{}, users: map[string]uint{}}
+		for _, record := range records {
+			if err := w.write(record); err != nil {
+				return fmt.Errorf("row %d: %w", record.Row, err)
+			}
+		}
+		if err := audit.Record(tx, opts.Actor, audit.Event{
+			Action:     audit.ImportRun,
+			TargetType: audit.TargetImport,
+			After: map[string]any{
+				"rows":               report.Rows,
+				"suggestions":        report.Suggestions,
+				"comments":           report.Comments,
+				"categories_created": report.CategoriesCreated,
+				"users_created":      report.UsersCreated,
+			},
+		}); err != nil {
+			return err
+		}
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return report, err
+	}
+	report.Committed = err == nil
+	return report, nil
+}
+
+func validate(record Record) []RowError {
+	var errs []RowError
+	fail := func(field, message string) {
+		errs = append(errs, RowError{Row: record.Row, Field: field, Message: message})
+	}
+
+	switch {
+	case record.Title == "":
+		fail("title", "is required")
+	case len(record.Title) > 255:
+		fail("title", "must be at most 255 characters")
+	}
+	if record.Content == "" {
+		fail("content", "is required")
+	}
+	if len(record.Category) > 255 {
+		fail("category", "must be at most 255 characters")
+	}
+	if !validEmail(record.AuthorEmail) {
+		fail("author_email", "must be an email address")
+	}
+	if record.Status != "" && !slices.Contains(models.Statuses, record.Status) {
+		fail("status", "must be one of "+strings.Join(models.Statuses, ", "))
+	}
+	if _, err := parseTime(record.CreatedAt); err != nil {
+		fail("created_at", err.Error())
+	}
+
+	for i, comment := range record.Comments {
+		field := "comments[" + strconv.Itoa(i) + "]"
+		if !validEmail(comment.AuthorEmail) {
+			fail(field+".author_email", "must be an email address")
+		}
+		if comment.Content == "" {
+			fail(field+".content", "is required")
+		}
+		if _, err := parseTime(comment.CreatedAt); err != nil {
+			fail(field+".created_at", err.Error())
+		}
+	}
+	return errs
+}
+
+func validEmail(value string) bool {
+	address, err := mail.ParseAddress(value)
+	return err == nil && address.Address == value && len(value) <= 255
+}
+
+// parseTime reads an optional timestamp; local time when it has no zone.
+func parseTime(value string) (models.DateTime, error) {
+	if value == "" {
+		return models.DateTime{}, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return models.DateTime{Time: t.Local()}, nil
+		}
+	}
+	return models.DateTime{}, errors.New("must be RFC 3339, YYYY-MM-DD HH:MM:SS or YYYY-MM-DD")
+}
+
+// writer creates the rows of valid records, remembering the categories and users it
+// resolved.
+type writer struct {
+	tx         *gorm.DB
+	report     *Report
+	categories map[string]uint
+	users      map[string]uint
+}
+
+func (w *writer) write(record Record) error {
+	categoryID, err := w.category(record.Category)
+	if err != nil {
+		return err
+	}
+	userID, err := w.user(record.AuthorEmail)
+	if err != nil {
+		return err
+	}
+
+	createdAt, _ := parseTime(record.CreatedAt)
+	status := record.Status
+	if status == "" {
+		status = models.StatusSuggestion
+	}
+	suggestion := models.Suggestion{
+		Title:      record.Title,
+		Content:    record.Content,
+		CategoryId: categoryID,
+		Status:     status,
+		Votes:      record.Votes,
+		UserId:     userID,
+		CreatedAt:  createdAt,
+		UpdatedAt:  createdAt,
+	}
+	if err := w.tx.Create(&suggestion).Error; err != nil {
+		return err
+	}
+	w.report.Suggestions++
+
+	for _, item := range record.Comments {
+		userID, err := w.user(item.AuthorEmail)
+		if err != nil {
+			return err
+		}
+		// undated comments take the date of their suggestion rather than today's
+		createdAt, _ := parseTime(item.CreatedAt)
+		if createdAt.IsZero() {
+			createdAt = suggestion.CreatedAt
+		}
+		comment := models.Comment{
+			Content:      item.Content,
+			UserId:       userID,
+			SuggestionId: suggestion.Id,
+			CreatedAt:    createdAt,
+			UpdatedAt:    createdAt,
+		}
+		if err := w.tx.Omit("User", "Suggestion", "Replies").Create(&comment).Error; err != nil {
+			return err
+		}
+		w.report.Comments++
+	}
+	return nil
+}
+
+// category finds a category by name, ignoring case, or creates it. No name means
+// uncategorized.
+func (w *writer) category(name string) (uint, error) {
+	if name == "" {
+		return 0, nil
+	}
+	key := strings.ToLower(name)
+	if id, ok := w.categories[key]; ok {
+		return id, nil
+	}
+
+	var category models.Category
+	err := w.tx.Where("LOWER(name) = ?", key).Order("id").First(&category).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		category = models.Category{Name: name}
+		err = w.tx.Create(&category).Error
+		if err == nil {
+			w.report.CategoriesCreated = append(w.report.CategoriesCreated, name)
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	w.categories[key] = category.Id
+	return category.Id, nil
+}
+
+// user finds a user by email, ignoring case, or creates one named after the email. Deleted
+// users are matched too, since their email is still taken.
+func (w *writer) user(email string) (uint, error) {
+	key := strings.ToLower(email)
+	if id, ok := w.users[key]; ok {
+		return id, nil
+	}
+
+	var user models.User
+	err := w.tx.Unscoped().Where("LOWER(email) = ?", key).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		var username string
+		username, err = w.username(email)
+		if err != nil {
+			return 0, err
+		}
+		user = models.User{Username: username, Email: email}
+		err = w.tx.Create(&user).Error
+		if err == nil {
+			w.report.UsersCreated = append(w.report.UsersCreated, email)
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	w.users[key] = user.Id
+	return user.Id, nil
+}
+
+var usernameInvalid = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// username derives a free username from the local part of email: jane.doe, then
+// jane.doe2, jane.doe3...
+func (w *writer) username(email string) (string, error) {
+	local, _, _ := strings.Cut(email, "@")
+	base := usernameInvalid.ReplaceAllString(strings.ToLower(local), "")
+	if base == "" {
+		base = "user"
+	}
+
+	var taken []string
+	if err := w.tx.Unscoped().Model(&models.User{}).
+		Where("username = ? OR username LIKE ?", base, base+"%").
+		Pluck("username", &taken).Error; err != nil {
+		return "", err
+	}
+	username := base
+	for n := 2; slices.Contains(taken, username); n++ {
+		username = base + strconv.Itoa(n)
+	}
+	return username, nil
+}
@@ -0,0 +1,74 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	valid := Record{
+		Row:         2,
+		Title:       "Dark mode",
+		Content:     "Please",
+		Category:    "UI",
+		AuthorEmail: "ann@example.com",
+		Status:      "planned",
+		CreatedAt:   "2024-05-01 10:00:00",
+		Comments: []Comment{
+			{AuthorEmail: "bob@example.com", Content: "yes", CreatedAt: "2024-05-02T08:00:00Z"},
+			{AuthorEmail: "cy@example.com", Content: "me too", CreatedAt: "2024-05-03"},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		change func(*Record)
+		want   []RowError
+	}{
+		{"valid", func(r *Record) {}, nil},
+		{"only required fields", func(r *Record) {
+			*r = Record{Row: 2, Title: "t", Content: "c", AuthorEmail: "ann@example.com"}
+		}, nil},
+		{"missing title", func(r *Record) { r.Title = "" },
+			[]RowError{{2, "title", "is required"}}},
+		{"long title", func(r *Record) { r.Title = strings.Repeat("a", 256) },
+			[]RowError{{2, "title", "must be at most 255 characters"}}},
+		{"missing content", func(r *Record) { r.Content = "" },
+			[]RowError{{2, "content", "is required"}}},
+		{"long category", func(r *Record) { r.Category = strings.Repeat("a", 256) },
+			[]RowError{{2, "category", "must be at most 255 characters"}}},
+		{"missing author", func(r *Record) { r.AuthorEmail = "" },
+			[]RowError{{2, "author_email", "must be an email address"}}},
+		{"author with a name", func(r *Record) { r.AuthorEmail = "Ann <ann@example.com>" },
+			[]RowError{{2, "author_email", "must be an email address"}}},
+		{"unknown status", func(r *Record) { r.Status = "done" },
+			[]RowError{{2, "status", "must be one of suggestion, planned, in-progress, live"}}},
+		{"bad time", func(r *Record) { r.CreatedAt = "yesterday" },
+			[]RowError{{2, "created_at", "must be RFC 3339, YYYY-MM-DD HH:MM:SS or YYYY-MM-DD"}}},
+		{"bad comment", func(r *Record) {
+			r.Comments = []Comment{r.Comments[0], {AuthorEmail: "nobody", CreatedAt: "05/03/2024"}}
+		}, []RowError{
+			{2, "comments[1].author_email", "must be an email address"},
+			{2, "comments[1].content", "is required"},
+			{2, "comments[1].created_at", "must be RFC 3339, YYYY-MM-DD HH:MM:SS or YYYY-MM-DD"},
+		}},
+		{"every error is reported", func(r *Record) {
+			r.Title, r.Content, r.AuthorEmail = "", "", "ann"
+		}, []RowError{
+			{2, "title", "is required"},
+			{2, "content", "is required"},
+			{2, "author_email", "must be an email address"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := valid
+			record.Comments = append([]Comment(nil), valid.Comments...)
+			tt.change(&record)
+			if got := validate(record); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validate = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Input formats.
+const (
+	CSV  = "csv"
+	JSON = "json"
+)
+
+// Formats lists the accepted input formats.
+var Formats = []string{CSV, JSON}
+
+// ErrMalformed is returned, wrapped, when the input can't be read as a whole: broken
+// CSV or JSON, or a CSV header without the required columns.
+var ErrMalformed = errors.New("malformed input")
+
+// requiredColumns must be in the CSV header. Columns are named like the JSON fields of
+// Record, in any order and case; unknown columns are ignored.
+var requiredColumns = []string{"title", "content", "author_email"}
+
+// parse reads records in format. Values that don't fit a field, like votes that aren't
+// a number, are reported as row errors rather than failing the whole input.
+func parse(format string, r io.Reader) ([]Record, []RowError, error) {
+	switch format {
+	case CSV:
+		return parseCSV(r)
+	case JSON:
+		return parseJSON(r)
+	default:
+		return nil, nil, fmt.Errorf("%w: unknown format %q", ErrMalformed, format)
+	}
+}
+
+// parseCSV reads one suggestion per line. The comments cell holds one comment per
+// line, written "author@example.com: text".
+func parseCSV(r io.Reader) ([]Record, []RowError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	for _, name := range requiredColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, nil, fmt.Errorf("%w: missing column %q", ErrMalformed, name)
+		}
+	}
+
+	var records []Record
+	var rowErrors []RowError
+	for {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		value := func(column string) string {
+			i, ok := columns[column]
+			if !ok || i >= len(fields) {
+				return ""
+			}
+			return strings.TrimSpace(fields[i])
+		}
+
+		record := Record{
+			Row:         line,
+			Title:       value("title"),
+			Content:     value("content"),
+			Category:    value("category"),
+			AuthorEmail: value("author_email"),
+			Status:      value("status"),
+			CreatedAt:   value("created_at"),
+		}
+		if votes := value("votes"); votes != "" {
+			record.Votes, err = strconv.Atoi(votes)
+			if err != nil {
+				rowErrors = append(rowErrors, RowError{Row: line, Field: "votes", Message: "must be a whole number"})
+			}
+		}
+		for _, entry := range strings.Split(value("comments"), "\n") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			email, content, _ := strings.Cut(entry, ":")
+			record.Comments = append(record.Comments, Comment{
+				AuthorEmail: strings.TrimSpace(email),
+				Content:     strings.TrimSpace(content),
+			})
+		}
+		records = append(records, record)
+	}
+	return records, rowErrors, nil
+}
+
+// parseJSON reads an array of records.
+func parseJSON(r io.Reader) ([]Record, []RowError, error) {
+	var records []Record
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	for i := range records {
+		records[i].Row = i + 1
+	}
+	return records, nil, nil
+}
@@ -0,0 +1,93 @@
+package importer
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCSV(t *testing.T) {
+	input := "\ufeffTitle, Content ,AUTHOR_EMAIL,Votes,Comments,Extra\n" +
+		"Dark mode,Please,ann@example.com,3,\"bob@example.com: yes\n cy@example.com: me too \",x\n" +
+		"Export, CSV ,bob@example.com\n" +
+		"Bad votes,c,cy@example.com,many,,\n"
+
+	records, rowErrors, err := parse(CSV, strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := []Record{
+		{Row: 2, Title: "Dark mode", Content: "Please", AuthorEmail: "ann@example.com", Votes: 3, Comments: []Comment{
+			{AuthorEmail: "bob@example.com", Content: "yes"},
+			{AuthorEmail: "cy@example.com", Content: "me too"},
+		}},
+		{Row: 4, Title: "Export", Content: "CSV", AuthorEmail: "bob@example.com"},
+		{Row: 5, Title: "Bad votes", Content: "c", AuthorEmail: "cy@example.com"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("records = %+v, want %+v", records, want)
+	}
+	wantErrors := []RowError{{Row: 5, Field: "votes", Message: "must be a whole number"}}
+	if !reflect.DeepEqual(rowErrors, wantErrors) {
+		t.Errorf("row errors = %+v, want %+v", rowErrors, wantErrors)
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	input := `[
+		{"title": "Dark mode", "content": "Please", "author_email": "ann@example.com", "votes": 3,
+		 "status": "planned", "comments": [{"author_email": "bob@example.com", "content": "yes"}]},
+		{"title": "Export"}
+	]`
+
+	records, rowErrors, err := parse(JSON, strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := []Record{
+		{Row: 1, Title: "Dark mode", Content: "Please", AuthorEmail: "ann@example.com", Votes: 3, Status: "planned",
+			Comments: []Comment{{AuthorEmail: "bob@example.com", Content: "yes"}}},
+		{Row: 2, Title: "Export"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("records = %+v, want %+v", records, want)
+	}
+	if len(rowErrors) != 0 {
+		t.Errorf("row errors = %+v, want none", rowErrors)
+	}
+}
+
+func TestParseEmpty(t *testing.T) {
+	for _, format := range Formats {
+		t.Run(format, func(t *testing.T) {
+			records, rowErrors, err := parse(format, strings.NewReader(""))
+			if err != nil || records != nil || rowErrors != nil {
+				t.Errorf("parse of empty input = %v, %v, %v, want nothing", records, rowErrors, err)
+			}
+		})
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		input  string
+	}{
+		{"unknown format", "xml", "<suggestions/>"},
+		{"missing column", CSV, "title,content\na,b\n"},
+		{"broken quote", CSV, "title,content,author_email\n\"a,b,c\n"},
+		{"broken json", JSON, `[{"title": "a"`},
+		{"not an array", JSON, `{"title": "a"}`},
+		{"wrong type", JSON, `[{"votes": "many"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := parse(tt.format, strings.NewReader(tt.input))
+			if !errors.Is(err, ErrMalformed) {
+				t.Errorf("parse error = %v, want ErrMalformed", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,332 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	mathrand "math/rand/v2"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"feedback-io.backend/audit"
+	"feedback-io.backend/config"
+	"feedback-io.backend/metrics"
+	"feedback-io.backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// maxBackoff caps the delay between two attempts.
+	maxBackoff = time.Hour
+	errorLimit = 1024
+)
+
+// Handler runs one job. payload is the value given to Enqueue, encoded as JSON. A returned
+// error schedules a retry unless it is wrapped with Permanent or the attempts ran out.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+var (
+	handlersMu sync.RWMutex
+	handlers   = map[string]Handler{}
+)
+
+// Register sets the handler of kind. Packages register their jobs in init, so every
+// replica can run every job.
+func Register(kind string, handler Handler) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	if _, ok := handlers[kind]; ok {
+		panic("jobs: handler already registered for " + kind)
+	}
+	handlers[kind] = handler
+}
+
+func handlerFor(kind string) (Handler, bool) {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+	handler, ok := handlers[kind]
+	return handler, ok
+}
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying, e.g. a payload that can't be decoded.
+func Permanent(err error) error {
+	return permanentError{err}
+}
+
+// Enqueue adds a job that runs as soon as a worker is free. Pass the request's database
+// handle, or a transaction to queue the job only if the transaction commits.
+func Enqueue(db *gorm.DB, kind string, payload any) (models.Job, error) {
+	return EnqueueAt(db, kind, payload, time.Now())
+}
+
+// EnqueueAt adds a job that runs at runAt or later.
+func EnqueueAt(db *gorm.DB, kind string, payload any, runAt time.Time) (models.Job, error) {
+	job := models.Job{
+		Kind:        kind,
+		Status:      models.JobPending,
+		MaxAttempts: config.GetEnvInt("JOB_MAX_ATTEMPTS", 5),
+		RunAt:       models.DateTime{Time: runAt},
+	}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return job, fmt.Errorf("encode %s job: %w", kind, err)
+	}
+	job.Payload = encoded
+
+	if err := db.Create(&job).Error; err != nil {
+		return job, err
+	}
+	if !runAt.After(time.Now()) {
+		Wake()
+	}
+	return job, nil
+}
+
+// Retry schedules a failed job again, now, with a fresh set of attempts, and records it in
+// the audit log. It returns gorm.ErrRecordNotFound when the job is not failed (anymore).
+func Retry(db *gorm.DB, actor audit.Actor, job *models.Job) error {
+	before := map[string]any{"status": job.Status, "attempts": job.Attempts, "last_error": job.LastError}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(job).
+			Where("status = ?", models.JobFailed).
+			Updates(map[string]any{
+				"status":      models.JobPending,
+				"attempts":    0,
+				"run_at":      models.DateTime{Time: time.Now()},
+				"finished_at": nil,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.First(job, job.Id).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, actor, audit.Event{
+			Action:     audit.JobRetry,
+			TargetType: audit.TargetJob,
+			TargetId:   job.Id,
+			Before:     before,
+			After:      map[string]any{"status": job.Status, "attempts": job.Attempts, "run_at": job.RunAt},
+		})
+	})
+	if err != nil {
+		return err
+	}
+	Wake()
+	return nil
+}
+
+// Pool runs queued jobs with a fixed number of workers. Jobs are claimed with
+// SELECT ... FOR UPDATE SKIP LOCKED, so several replicas can share the queue and a job
+// runs on one of them at a time. A job whose worker died is claimed again once its lease
+// expired.
+type Pool struct {
+	db           *gorm.DB
+	workers      int
+	pollInterval time.Duration
+	backoff      time.Duration
+	lease        time.Duration
+	name         string
+
+	wake   chan struct{}
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var pool *Pool
+
+// Start runs the worker pool with settings from the environment. Call it after the
+// database is connected.
+func Start() {
+	hostname, _ := os.Hostname()
+	p := &Pool{
+		db:           config.DB,
+		workers:      max(config.GetEnvInt("JOB_WORKERS", 4), 1),
+		pollInterval: config.GetEnvDuration("JOB_POLL_INTERVAL", 5*time.Second),
+		backoff:      config.GetEnvDuration("JOB_RETRY_BACKOFF", 10*time.Second),
+		lease:        config.GetEnvDuration("JOB_LEASE", 10*time.Minute),
+		name:         hostname + ":" + strconv.Itoa(os.Getpid()),
+		wake:         make(chan struct{}, 1),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.wg.Add(p.workers)
+	for i := range p.workers {
+		go p.work(ctx, fmt.Sprintf("%s/%d", p.name, i))
+	}
+
+	pool = p
+}
+
+// Stop stops claiming jobs and waits for running ones to finish until ctx is done. Jobs
+// still running then are left to their lease: another worker runs them again once it
+// expires.
+func Stop(ctx context.Context) {
+	if pool == nil {
+		return
+	}
+	pool.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		pool.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		slog.Warn("stopped waiting for running jobs; they run again once their lease expires", "lease", pool.lease)
+	}
+}
+
+// Wake makes an idle worker look for due jobs now instead of at the next poll.
+func Wake() {
+	if pool == nil {
+		return
+	}
+	select {
+	case pool.wake <- struct{}{}:
+	default:
+	}
+}
+
+// work runs due jobs one at a time until ctx is cancelled.
+func (p *Pool) work(ctx context.Context, worker string) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			job, ok, err := p.claim(worker)
+			if err != nil {
+				slog.Error("failed to claim job", "worker", worker, "error", err)
+				break
+			}
+			if !ok {
+				break
+			}
+			// there may be more: let another idle worker look too
+			Wake()
+			p.run(job)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-p.wake:
+		}
+	}
+}
+
+// claim locks the next due job, skipping rows locked by other workers, and marks it running.
+func (p *Pool) claim(worker string) (models.Job, bool, error) {
+	var job models.Job
+	now := time.Now()
+
+	err := p.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_at <= ?)",
+				models.JobPending, models.DateTime{Time: now},
+				models.JobRunning, models.DateTime{Time: now.Add(-p.lease)}).
+			Order("run_at").
+			Limit(1).
+			Find(&job).Error
+		if err != nil || job.Id == 0 {
+			return err
+		}
+
+		lockedAt := models.DateTime{Time: now}
+		job.Status = models.JobRunning
+		job.Attempts++
+		job.LockedBy = worker
+		job.LockedAt = &lockedAt
+		return tx.Model(&job).Updates(map[string]any{
+			"status":    job.Status,
+			"attempts":  job.Attempts,
+			"locked_by": job.LockedBy,
+			"locked_at": lockedAt,
+		}).Error
+	})
+	return job, err == nil && job.Id != 0, err
+}
+
+// run calls the job's handler and records the outcome.
+func (p *Pool) run(job models.Job) {
+	log := slog.With("job_id", job.Id, "kind", job.Kind, "attempt", job.Attempts)
+
+	// not bound to the pool context, so Stop lets running jobs finish
+	ctx, cancel := context.WithTimeout(context.Background(), p.lease)
+	err := p.call(ctx, job)
+	cancel()
+
+	updates := map[string]any{"locked_by": "", "locked_at": nil}
+	var permanent permanentError
+	switch {
+	case err == nil:
+		updates["status"] = models.JobSucceeded
+		updates["last_error"] = ""
+		updates["finished_at"] = models.DateTime{Time: time.Now()}
+		metrics.JobRun(job.Kind, models.JobSucceeded)
+	case errors.As(err, &permanent) || job.Attempts >= job.MaxAttempts:
+		updates["status"] = models.JobFailed
+		updates["last_error"] = models.Truncate(err.Error(), errorLimit)
+		updates["finished_at"] = models.DateTime{Time: time.Now()}
+		metrics.JobRun(job.Kind, models.JobFailed)
+		log.Warn("job failed permanently", "error", err)
+	default:
+		updates["status"] = models.JobPending
+		updates["last_error"] = models.Truncate(err.Error(), errorLimit)
+		updates["run_at"] = models.DateTime{Time: time.Now().Add(p.delay(job.Attempts))}
+		metrics.JobRun(job.Kind, "retrying")
+		log.Info("job failed, retrying", "error", err)
+	}
+
+	// only record the outcome if the lease wasn't taken over in the meantime
+	if err := p.db.Model(&models.Job{}).
+		Where("id = ? AND status = ? AND locked_by = ?", job.Id, models.JobRunning, job.LockedBy).
+		Updates(updates).Error; err != nil {
+		log.Error("failed to record job outcome", "error", err)
+	}
+}
+
+// call runs the handler, turning a panic into a permanent failure.
+func (p *Pool) call(ctx context.Context, job models.Job) (err error) {
+	handler, ok := handlerFor(job.Kind)
+	if !ok {
+		return Permanent(fmt.Errorf("no handler registered for job kind %q", job.Kind))
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = Permanent(fmt.Errorf("panic: %v", recovered))
+		}
+	}()
+	return handler(ctx, job.Payload)
+}
+
+// delay is the wait before the next attempt: the base backoff doubled per failed
+// attempt, capped at maxBackoff, with up to 10% jitter.
+func (p *Pool) delay(attempts int) time.Duration {
+	delay := p.backoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxBackoff)
+	return delay + time.Duration(mathrand.Int64N(int64(delay/10)+1))
+}
@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// GormLogger adapts GORM's logger to slog, tagging every line with the request id
+// carried by the statement context (use DB.WithContext(c.UserContext())).
+type GormLogger struct {
+	Level         gormlogger.LogLevel
+	SlowThreshold time.Duration
+}
+
+// NewGormLogger reads DB_LOG_LEVEL (silent, error, warn, info) and DB_SLOW_THRESHOLD.
+func NewGormLogger() *GormLogger {
+	level := gormlogger.Warn
+	switch strings.ToLower(os.Getenv("DB_LOG_LEVEL")) {
+	case "silent":
+		level = gormlogger.Silent
+	case "error":
+		level = gormlogger.Error
+	case "info":
+		level = gormlogger.Info
+	}
+
+	threshold := 200 * time.Millisecond
+	if value := os.Getenv("DB_SLOW_THRESHOLD"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil {
+			threshold = parsed
+		}
+	}
+
+	return &GormLogger{Level: level, SlowThreshold: threshold}
+}
+
+func (l *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *l
+	clone.Level = level
+	return &clone
+}
+
+func (l *GormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.Level >= gormlogger.Info {
+		FromContext(ctx).InfoContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *GormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.Level >= gormlogger.Warn {
+		FromContext(ctx).WarnContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *GormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.Level >= gormlogger.Error {
+		FromContext(ctx).ErrorContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.Level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	sql, rows := fc()
+	attrs := []any{
+		"sql", sql,
+		"rows", rows,
+		"elapsed_ms", float64(elapsed.Microseconds()) / 1000,
+	}
+
+	log := FromContext(ctx)
+	switch {
+	case err != nil && l.Level >= gormlogger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		log.Log(ctx, slog.LevelError, "query failed", append(attrs, "error", err.Error())...)
+	case l.SlowThreshold > 0 && elapsed > l.SlowThreshold && l.Level >= gormlogger.Warn:
+		log.Log(ctx, slog.LevelWarn, "slow query", attrs...)
+	case l.Level >= gormlogger.Info:
+		log.Log(ctx, slog.LevelInfo, "query", attrs...)
+	}
+}
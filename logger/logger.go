@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type contextKey struct{}
+
+var requestIDKey = contextKey{}
+
+// Setup installs a JSON slog logger as the process default. The level is read from
+// LOG_LEVEL (debug, info, warn, error). The standard log package is routed through it too.
+func Setup() *slog.Logger {
+	l := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level: parseLevel(os.Getenv("LOG_LEVEL")),
+	}))
+	slog.SetDefault(l)
+	log.SetFlags(0)
+	return l
+}
+
+func parseLevel(value string) slog.Level {
+	switch strings.ToLower(value) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// WithRequestID returns a copy of ctx carrying the request id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID returns the request id stored in ctx, if any.
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// FromContext returns the default logger annotated with the request id and trace id found in ctx.
+func FromContext(ctx context.Context) *slog.Logger {
+	l := slog.Default()
+	if id := RequestID(ctx); id != "" {
+		l = l.With("request_id", id)
+	}
+	if ctx != nil {
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			l = l.With("trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
+		}
+	}
+	return l
+}
@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"log/slog"
+	"time"
+
+	"feedback-io.backend/auth"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const HeaderRequestID = "X-Request-ID"
+
+// maxRequestIDLength bounds client supplied ids so they can't bloat logs.
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware accepts the caller's X-Request-ID or generates one, echoes it back
+// in the response and stores it in c.UserContext() so GORM and other loggers can pick it up.
+func RequestIDMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(HeaderRequestID)
+		if id == "" || len(id) > maxRequestIDLength {
+			id = uuid.NewString()
+		}
+
+		c.Set(HeaderRequestID, id)
+		c.Locals("request_id", id)
+		c.SetUserContext(WithRequestID(c.UserContext(), id))
+
+		return c.Next()
+	}
+}
+
+// AccessLogMiddleware writes one structured log line per request.
+func AccessLogMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			if e, ok := err.(*fiber.Error); ok {
+				status = e.Code
+			} else {
+				status = fiber.StatusInternalServerError
+			}
+		}
+
+		attrs := []any{
+			"method", c.Method(),
+			"path", c.Path(),
+			"route", c.Route().Path,
+			"status", status,
+			"latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+			"ip", c.IP(),
+		}
+		if userID, ok := auth.UserID(c); ok {
+			attrs = append(attrs, "user_id", userID)
+		}
+		if err != nil {
+			attrs = append(attrs, "error", err.Error())
+		}
+
+		level := slog.LevelInfo
+		if status >= fiber.StatusInternalServerError {
+			level = slog.LevelError
+		} else if status >= fiber.StatusBadRequest {
+			level = slog.LevelWarn
+		}
+		FromContext(c.UserContext()).Log(c.UserContext(), level, "request", attrs...)
+
+		return err
+	}
+}
@@ -0,0 +1,73 @@
+package mailer
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"feedback-io.backend/config"
+)
+
+// Message is one email. Text and HTML are sent as alternatives of the same content.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+	// Headers are added as is, e.g. List-Unsubscribe.
+	Headers map[string]string
+}
+
+// Mailer sends email. SMTPMailer is used in production and WriterMailer (stdout or a
+// file) in development.
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// Default is the mailer used for notifications. It is nil (email disabled) until Setup runs.
+var Default Mailer
+
+// From is the sender address of every email.
+var From = "Feedback IO <no-reply@feedback-io.app>"
+
+// Setup selects the mailer from MAILER: "smtp", "file" (MAILER_FILE) or "stdout".
+// Email is disabled when MAILER is empty or "none".
+func Setup() {
+	if value := os.Getenv("MAIL_FROM"); value != "" {
+		From = value
+	}
+
+	switch kind := os.Getenv("MAILER"); kind {
+	case "smtp":
+		Default = &SMTPMailer{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     config.GetEnvInt("SMTP_PORT", 587),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+		}
+	case "file":
+		path := os.Getenv("MAILER_FILE")
+		if path == "" {
+			path = "mail.log"
+		}
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			log.Fatalf("Error opening MAILER_FILE: %v", err)
+		}
+		Default = &WriterMailer{W: file}
+	case "stdout":
+		Default = &WriterMailer{W: os.Stdout}
+	case "", "none":
+		log.Println("Email disabled")
+	default:
+		log.Fatalf("Unknown MAILER %q", kind)
+	}
+}
+
+// Send sends with the default mailer, doing nothing when email is disabled.
+func Send(ctx context.Context, message Message) error {
+	if Default == nil {
+		return nil
+	}
+	return Default.Send(ctx, message)
+}
@@ -0,0 +1,37 @@
+package mailer
+
+import (
+	"context"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+)
+
+// SMTPMailer sends through an SMTP relay, upgrading to TLS when the server offers STARTTLS.
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, message Message) error {
+	body, err := message.Bytes(From)
+	if err != nil {
+		return err
+	}
+
+	sender, err := mail.ParseAddress(From)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	addr := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+	return smtp.SendMail(addr, auth, sender.Address, []string{message.To}, body)
+}
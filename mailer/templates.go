@@ -0,0 +1,43 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var templateFiles embed.FS
+
+var (
+	textTemplates = texttemplate.Must(texttemplate.ParseFS(templateFiles, "templates/*.txt"))
+	htmlTemplates = htmltemplate.Must(htmltemplate.ParseFS(templateFiles, "templates/*.html"))
+)
+
+// Render builds the subject and bodies of the email called name from templates/<name>.txt,
+// which also defines "<name>.subject", and templates/<name>.html.
+func Render(name string, to string, data any) (Message, error) {
+	message := Message{To: to}
+
+	var buf bytes.Buffer
+	if err := textTemplates.ExecuteTemplate(&buf, name+".subject", data); err != nil {
+		return message, err
+	}
+	message.Subject = strings.TrimSpace(buf.String())
+
+	buf.Reset()
+	if err := textTemplates.ExecuteTemplate(&buf, name+".txt", data); err != nil {
+		return message, err
+	}
+	message.Text = buf.String()
+
+	buf.Reset()
+	if err := htmlTemplates.ExecuteTemplate(&buf, name+".html", data); err != nil {
+		return message, err
+	}
+	message.HTML = buf.String()
+
+	return message, nil
+}
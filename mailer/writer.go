@@ -0,0 +1,28 @@
+package mailer
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// WriterMailer writes each message to W instead of sending it, for local development.
+type WriterMailer struct {
+	W  io.Writer
+	mu sync.Mutex
+}
+
+func (m *WriterMailer) Send(ctx context.Context, message Message) error {
+	body, err := message.Bytes(From)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.W.Write(body); err != nil {
+		return err
+	}
+	_, err = io.WriteString(m.W, "\r\n")
+	return err
+}
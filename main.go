@@ -1,11 +1,30 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
+	"feedback-io.backend/apierror"
+	"feedback-io.backend/auth"
+	"feedback-io.backend/cache"
 	database "feedback-io.backend/config"
+	"feedback-io.backend/contentcheck"
+	"feedback-io.backend/events"
+	"feedback-io.backend/jobs"
+	"feedback-io.backend/logger"
+	"feedback-io.backend/mailer"
+	"feedback-io.backend/maintenance"
+	"feedback-io.backend/metrics"
+	"feedback-io.backend/middleware"
 	"feedback-io.backend/routes"
+	"feedback-io.backend/scheduler"
+	"feedback-io.backend/tracing"
+	"feedback-io.backend/webhooks"
 	"github.com/gofiber/fiber/v2"
 	"github.com/joho/godotenv"
 )
@@ -17,9 +36,54 @@ func main() {
 		log.Fatalf("Error loading .env file: %v", err)
 	}
 
+	logger.Setup()
+
+	// "import" runs a one-off import instead of the server
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		os.Exit(runImport(os.Args[2:]))
+	}
+	// "migrate" creates the missing tables and columns instead of running the server
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+	// "token" prints an access token instead of running the server
+	if len(os.Args) > 1 && os.Args[1] == "token" {
+		os.Exit(runToken(os.Args[2:]))
+	}
+
 	port := os.Getenv("PORT")
+	shutdownTimeout := database.GetEnvDuration("SHUTDOWN_TIMEOUT", 10*time.Second)
+
+	shutdownTracing, err := tracing.Setup(context.Background())
+	if err != nil {
+		log.Fatalf("Error setting up tracing: %v", err)
+	}
+
+	httpConfig := database.GetHTTPConfig()
+
+	app := fiber.New(fiber.Config{
+		ErrorHandler: apierror.Handler,
+		BodyLimit:    httpConfig.BodyLimit,
+		// client addresses (rate limits, logs, audit) come from ProxyHeader only when
+		// the request was forwarded by a trusted proxy
+		ProxyHeader:             httpConfig.ProxyHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          httpConfig.TrustedProxies,
+	})
+
+	app.Use(middleware.CORS(httpConfig))
+	app.Use(middleware.SecurityHeaders(httpConfig))
+
+	routes.Health(app)
+	routes.Metrics(app)
+	routes.Docs(app, httpConfig.DocsContentSecurityPolicy)
 
-	app := fiber.New()
+	app.Use(tracing.Middleware())
+	app.Use(logger.RequestIDMiddleware())
+	app.Use(logger.AccessLogMiddleware())
+	app.Use(metrics.Middleware())
+	app.Use(auth.Identify())
+	app.Use(apierror.Middleware())
 
 	app.Use(func(c *fiber.Ctx) error {
 		c.Set("Content-Type", "application/json")
@@ -27,9 +91,70 @@ func main() {
 	})
 
 	database.ConnectDatabase()
+	// public reads filter on hidden_at and votes need their table: say so up front rather
+	// than with a 500 on the first request
+	if pending, err := database.PendingMigrations(database.DB); err != nil {
+		log.Printf("Error checking the database schema: %v", err)
+	} else if len(pending) > 0 {
+		log.Printf("Database schema is out of date, run `migrate`; missing: %s", strings.Join(pending, ", "))
+	}
+	cache.Setup()
+	mailer.Setup()
+	contentcheck.Setup(routes.RateLimitStore)
+	webhooks.Start()
+	jobs.Start()
+	scheduler.Start(maintenance.Tasks()...)
 
 	routes.Setups(app)
 
-	log.Fatal(app.Listen(":" + port))
+	// Listen in the background so the main goroutine can wait for a shutdown signal
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- app.Listen(":" + port)
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	signalled := false
+	select {
+	case err := <-serverErr:
+		if err != nil {
+			log.Printf("Server stopped: %v", err)
+		}
+	case sig := <-quit:
+		log.Printf("Received %s, shutting down (timeout %s)", sig, shutdownTimeout)
+		signalled = true
+	}
+
+	// one deadline for draining requests and jobs, which must fit the orchestrator's grace period
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if signalled {
+		// end event streams, which would otherwise hold their connections open
+		events.Default.Close()
+		// Stop accepting new connections and wait for in-flight requests to finish
+		if err := app.ShutdownWithContext(shutdownCtx); err != nil {
+			log.Printf("Error during server shutdown: %v", err)
+		}
+	}
+
+	// stop queueing webhook deliveries and let running jobs record their outcome before the pool closes
+	webhooks.Stop()
+	jobs.Stop(shutdownCtx)
+	scheduler.Stop()
+
+	if err := database.CloseDatabase(); err != nil {
+		log.Printf("Error closing database: %v", err)
+	}
+
+	ctx, cancelFlush := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelFlush()
+	if err := shutdownTracing(ctx); err != nil {
+		log.Printf("Error flushing traces: %v", err)
+	}
+
+	log.Println("Server exited")
 
 }
@@ -0,0 +1,165 @@
+package maintenance
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"time"
+
+	"feedback-io.backend/audit"
+	"feedback-io.backend/cache"
+	"feedback-io.backend/config"
+	"feedback-io.backend/idempotency"
+	"feedback-io.backend/models"
+	"feedback-io.backend/notifications"
+	"feedback-io.backend/scheduler"
+	"gorm.io/gorm"
+)
+
+const (
+	// trendingGravity is how fast the trending score of a suggestion decays with age.
+	trendingGravity = 1.5
+	// trendingWindow is the age after which a suggestion no longer trends.
+	trendingWindow = 30 * 24 * time.Hour
+)
+
+// Tasks is the periodic maintenance of the service, for scheduler.Start.
+func Tasks() []scheduler.Task {
+	return []scheduler.Task{
+		{Name: "purge-deleted", Schedule: "30 3 * * *", Run: PurgeDeleted},
+		{Name: "send-digests", Schedule: "0 * * * *", Run: sendDigests},
+		{Name: "recompute-trending", Schedule: "*/15 * * * *", Run: RecomputeTrending},
+		{Name: "expire-idempotency-keys", Schedule: "15 * * * *", Run: expireIdempotencyKeys},
+	}
+}
+
+// PurgeDeleted permanently removes suggestions, comments and replies that were soft
+// deleted more than PURGE_DELETED_AFTER (30 days by default) ago, along with the
+// comments, replies, votes, subscriptions and notifications of purged suggestions and the
+// reports of everything purged.
+func PurgeDeleted(ctx context.Context) error {
+	after := config.GetEnvDuration("PURGE_DELETED_AFTER", 30*24*time.Hour)
+	cutoff := models.DateTime{Time: time.Now().Add(-after)}
+
+	counts := map[string]int64{}
+	err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		suggestions := func() *gorm.DB {
+			return tx.Unscoped().Model(&models.Suggestion{}).Select("id").Where("deleted_at <= ?", cutoff)
+		}
+		comments := func() *gorm.DB {
+			return tx.Unscoped().Model(&models.Comment{}).Select("id").
+				Where("deleted_at <= ? OR suggestion_id IN (?)", cutoff, suggestions())
+		}
+		replies := func() *gorm.DB {
+			return tx.Unscoped().Model(&models.Reply{}).Select("id").
+				Where("deleted_at <= ? OR comment_id IN (?)", cutoff, comments())
+		}
+
+		// children first, so the statements don't depend on cascading foreign keys, and
+		// reports before the content they point at, which the subqueries still need
+		steps := []struct {
+			table string
+			query *gorm.DB
+			model any
+		}{
+			{"reports", tx.Where("(target_type = ? AND target_id IN (?)) OR (target_type = ? AND target_id IN (?)) OR (target_type = ? AND target_id IN (?))",
+				models.ReportSuggestion, suggestions(), models.ReportComment, comments(), models.ReportReply, replies()), &models.Report{}},
+			{"votes", tx.Where("suggestion_id IN (?)", suggestions()), &models.Vote{}},
+			{"replies", tx.Unscoped().Where("deleted_at <= ? OR comment_id IN (?)", cutoff, comments()), &models.Reply{}},
+			{"comments", tx.Unscoped().Where("deleted_at <= ? OR suggestion_id IN (?)", cutoff, suggestions()), &models.Comment{}},
+			{"notifications", tx.Where("suggestion_id IN (?)", suggestions()), &models.Notification{}},
+			{"subscriptions", tx.Where("suggestion_id IN (?)", suggestions()), &models.Subscription{}},
+			{"suggestions", tx.Unscoped().Where("deleted_at <= ?", cutoff), &models.Suggestion{}},
+		}
+		purged := false
+		for _, step := range steps {
+			result := step.query.Delete(step.model)
+			if result.Error != nil {
+				return result.Error
+			}
+			counts[step.table] = result.RowsAffected
+			purged = purged || result.RowsAffected > 0
+		}
+		if !purged {
+			return nil
+		}
+		return audit.Record(tx, audit.System, audit.Event{
+			Action:     audit.ContentPurge,
+			TargetType: audit.TargetTask,
+			TargetId:   "purge-deleted",
+			After:      map[string]any{"deleted_before": cutoff, "rows": counts},
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	slog.Info("purged soft deleted rows", "cutoff", cutoff.Time, "replies", counts["replies"],
+		"comments", counts["comments"], "suggestions", counts["suggestions"],
+		"votes", counts["votes"], "reports", counts["reports"])
+	return nil
+}
+
+// RecomputeTrending updates the trending score of suggestions created in the last 30
+// days: votes divided by (age in hours + 2)^1.5, so new suggestions with a few votes
+// rank above old ones with many. Older suggestions drop to 0.
+func RecomputeTrending(ctx context.Context) error {
+	db := config.DB.WithContext(ctx)
+	now := time.Now()
+	since := models.DateTime{Time: now.Add(-trendingWindow)}
+
+	if err := db.Model(&models.Suggestion{}).
+		Where("created_at < ? AND trending_score <> 0", since).
+		UpdateColumn("trending_score", 0).Error; err != nil {
+		return err
+	}
+
+	updated := 0
+	var batch []models.Suggestion
+	err := db.Select("id", "votes", "created_at", "trending_score").
+		Where("created_at >= ?", since).
+		FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+			for _, suggestion := range batch {
+				score := trendingScore(suggestion.Votes, now.Sub(suggestion.CreatedAt.Time))
+				if math.Abs(score-suggestion.TrendingScore) < 1e-9 {
+					continue
+				}
+				// UpdateColumn keeps updated_at, which the listing cache validators rely on
+				if err := db.Model(&models.Suggestion{}).Where("id = ?", suggestion.Id).
+					UpdateColumn("trending_score", score).Error; err != nil {
+					return err
+				}
+				updated++
+			}
+			return ctx.Err()
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	if updated > 0 {
+		cache.Invalidate(ctx, cache.SuggestionsPrefix)
+	}
+	slog.Info("recomputed trending scores", "updated", updated)
+	return nil
+}
+
+func trendingScore(votes int, age time.Duration) float64 {
+	hours := math.Max(age.Hours(), 0)
+	return float64(votes) / math.Pow(hours+2, trendingGravity)
+}
+
+func sendDigests(ctx context.Context) error {
+	sent, err := notifications.SendDueDigests(ctx)
+	slog.Info("queued digests", "queued", sent)
+	return err
+}
+
+func expireIdempotencyKeys(ctx context.Context) error {
+	deleted, err := idempotency.DeleteExpired(config.DB.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	slog.Info("deleted expired idempotency keys", "deleted", deleted)
+	return nil
+}
@@ -0,0 +1,163 @@
+package metrics
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "feedbackio"
+
+// Registry holds every collector exposed on /metrics. A dedicated registry keeps
+// the output free of collectors registered by third-party packages.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests processed, by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency, by method and route template.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "GORM statement latency, by operation and table.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+
+	suggestionsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "suggestions_created_total",
+		Help:      "Suggestions successfully created.",
+	})
+
+	votesCast = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "votes_cast_total",
+		Help:      "Votes successfully cast, by direction.",
+	}, []string{"direction"})
+
+	webhookDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_delivery_attempts_total",
+		Help:      "Webhook delivery attempts, by result (succeeded, retrying, failed).",
+	}, []string{"result"})
+
+	jobRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "job_runs_total",
+		Help:      "Background job runs, by kind and result (succeeded, retrying, failed).",
+	}, []string{"kind", "result"})
+
+	taskRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "scheduled_task_runs_total",
+		Help:      "Scheduled task runs, by task and result (succeeded, failed).",
+	}, []string{"task", "result"})
+
+	contentFlagged = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "content_flagged_total",
+		Help:      "User content held for moderation, by content check.",
+	}, []string{"check"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		dbQueryDuration,
+		suggestionsCreated,
+		votesCast,
+		webhookDeliveries,
+		jobRuns,
+		taskRuns,
+		contentFlagged,
+	)
+}
+
+// RegisterDBStats exposes the connection pool statistics of db (sql.DB.Stats).
+func RegisterDBStats(db *sql.DB, name string) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler serves the registry in the Prometheus text format.
+func Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+}
+
+// Middleware records request counts and latency per route template (e.g. /suggestions/:id<int>)
+// so that cardinality stays bounded regardless of the ids in the URL.
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			if e, ok := err.(*fiber.Error); ok {
+				status = e.Code
+			} else {
+				status = fiber.StatusInternalServerError
+			}
+		}
+
+		// when nothing matched, the last route seen is a "/" middleware
+		route := c.Route().Path
+		if status == fiber.StatusNotFound && route == "/" {
+			route = "unmatched"
+		}
+
+		method := c.Method()
+		httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+		httpDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
+
+// SuggestionCreated increments the suggestions created counter.
+func SuggestionCreated() {
+	suggestionsCreated.Inc()
+}
+
+// VoteCast increments the votes counter for direction ("up" or "down").
+func VoteCast(direction string) {
+	votesCast.WithLabelValues(direction).Inc()
+}
+
+// WebhookAttempt increments the webhook delivery counter for result.
+func WebhookAttempt(result string) {
+	webhookDeliveries.WithLabelValues(result).Inc()
+}
+
+// JobRun increments the background job counter for kind and result.
+func JobRun(kind, result string) {
+	jobRuns.WithLabelValues(kind, result).Inc()
+}
+
+// TaskRun increments the scheduled task counter for task and result.
+func TaskRun(task, result string) {
+	taskRuns.WithLabelValues(task, result).Inc()
+}
+
+// ContentFlagged increments the flagged content counter for check.
+func ContentFlagged(check string) {
+	contentFlagged.WithLabelValues(check).Inc()
+}
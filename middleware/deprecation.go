@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Deprecated marks responses of a legacy route with the Deprecation (RFC 9745) and
+// Sunset (RFC 8594) headers and links to the same path under successorPrefix.
+func Deprecated(successorPrefix string, sunset time.Time) fiber.Handler {
+	sunsetHeader := sunset.UTC().Format(http.TimeFormat)
+	return func(c *fiber.Ctx) error {
+		c.Set("Deprecation", "true")
+		c.Set("Sunset", sunsetHeader)
+		c.Set(fiber.HeaderLink, "<"+successorPrefix+c.OriginalURL()+`>; rel="successor-version"`)
+		return c.Next()
+	}
+}
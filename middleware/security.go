@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"strings"
+
+	"feedback-io.backend/config"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/helmet"
+)
+
+// CORS allows the configured frontend origins to call the API and read the
+// custom response headers it relies on.
+func CORS(cfg *config.HTTPConfig) fiber.Handler {
+	return cors.New(cors.Config{
+		AllowOrigins:     strings.Join(cfg.AllowedOrigins, ","),
+		AllowCredentials: cfg.AllowCredentials,
+		AllowMethods:     "GET,POST,PUT,PATCH,DELETE,HEAD,OPTIONS",
+		AllowHeaders:     "Origin,Content-Type,Accept,Authorization,X-Request-ID,If-None-Match,If-Modified-Since,Idempotency-Key,Last-Event-ID",
+		ExposeHeaders:    "X-Request-ID,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset,RateLimit-Policy,Retry-After,Deprecation,Sunset,Link,ETag,Last-Modified,Idempotent-Replayed",
+		MaxAge:           int(cfg.PreflightMaxAge.Seconds()),
+	})
+}
+
+// SecurityHeaders sets HSTS (on HTTPS requests), nosniff, frame options and the API
+// content security policy. Routes serving HTML override the policy with ContentSecurityPolicy.
+func SecurityHeaders(cfg *config.HTTPConfig) fiber.Handler {
+	return helmet.New(helmet.Config{
+		HSTSMaxAge:            cfg.HSTSMaxAge,
+		XFrameOptions:         cfg.FrameOptions,
+		ContentTypeNosniff:    "nosniff",
+		ContentSecurityPolicy: cfg.ContentSecurityPolicy,
+		ReferrerPolicy:        "no-referrer",
+	})
+}
+
+// ContentSecurityPolicy replaces the policy set by SecurityHeaders for one route.
+func ContentSecurityPolicy(policy string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentSecurityPolicy, policy)
+		return c.Next()
+	}
+}
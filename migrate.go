@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	database "feedback-io.backend/config"
+)
+
+// runMigrate implements `migrate [-dry-run]`, which creates the tables and columns the
+// models need and the database lacks. Run it before starting a new version. It prints
+// what was missing and returns the exit status: 1 when the migration failed, 2 on usage
+// errors.
+func runMigrate(args []string) int {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "list the missing tables and columns without creating them")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: migrate [-dry-run]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return 2
+	}
+
+	database.ConnectDatabase()
+	defer database.CloseDatabase()
+
+	pending, err := database.PendingMigrations(database.DB)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "migration failed:", err)
+		return 1
+	}
+	if len(pending) == 0 {
+		fmt.Println("schema is up to date")
+		return 0
+	}
+	for _, name := range pending {
+		fmt.Println("missing:", name)
+	}
+	if *dryRun {
+		return 0
+	}
+
+	if err := database.MigrateDB(database.DB); err != nil {
+		fmt.Fprintln(os.Stderr, "migration failed:", err)
+		return 1
+	}
+	fmt.Printf("created %d tables and columns\n", len(pending))
+	return 0
+}
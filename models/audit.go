@@ -0,0 +1,35 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrAppendOnly is returned when updating or deleting an AuditEvent.
+var ErrAppendOnly = errors.New("audit events are append-only")
+
+// AuditEvent records who performed an administrative or destructive action, on what, and
+// the state of the target before and after it. ActorId is 0 for the command line and the
+// service itself. Events are only ever inserted.
+type AuditEvent struct {
+	Id         uint            `json:"id" gorm:"column:id;type:INT(10) UNSIGNED NOT NULL AUTO_INCREMENT;primaryKey"`
+	ActorId    uint            `json:"actor_id" gorm:"column:actor_id;type:INT(10) UNSIGNED NOT NULL;default:0;index"`
+	Action     string          `json:"action" gorm:"column:action;type:varchar(64);not null;index"`
+	TargetType string          `json:"target_type" gorm:"column:target_type;type:varchar(32);not null;index:idx_audit_events_target"`
+	TargetId   string          `json:"target_id" gorm:"column:target_id;type:varchar(64);not null;index:idx_audit_events_target"`
+	Before     json.RawMessage `json:"before" gorm:"column:before_data;type:mediumtext"`
+	After      json.RawMessage `json:"after" gorm:"column:after_data;type:mediumtext"`
+	Ip         string          `json:"ip" gorm:"column:ip;type:varchar(45)"`
+	RequestId  string          `json:"request_id" gorm:"column:request_id;type:varchar(128)"`
+	CreatedAt  DateTime        `json:"created_at" gorm:"column:created_at;type:DATETIME;index"`
+}
+
+func (e *AuditEvent) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAppendOnly
+}
+
+func (e *AuditEvent) BeforeDelete(tx *gorm.DB) error {
+	return ErrAppendOnly
+}
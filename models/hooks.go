@@ -0,0 +1,179 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GORM only manages CreatedAt/UpdatedAt automatically for time.Time fields, so the
+// DateTime timestamps are maintained by these hooks.
+
+func touchCreate(createdAt, updatedAt *DateTime) {
+	now := DateTime{time.Now()}
+	if createdAt.IsZero() {
+		*createdAt = now
+	}
+	if updatedAt.IsZero() {
+		*updatedAt = now
+	}
+}
+
+func touchUpdate(tx *gorm.DB) {
+	tx.Statement.SetColumn("updated_at", DateTime{time.Now()})
+}
+
+func (s *Suggestion) BeforeCreate(tx *gorm.DB) error {
+	touchCreate(&s.CreatedAt, &s.UpdatedAt)
+	return nil
+}
+
+func (s *Suggestion) BeforeUpdate(tx *gorm.DB) error {
+	touchUpdate(tx)
+	return nil
+}
+
+func (c *Comment) BeforeCreate(tx *gorm.DB) error {
+	touchCreate(&c.CreatedAt, &c.UpdatedAt)
+	return nil
+}
+
+func (c *Comment) BeforeUpdate(tx *gorm.DB) error {
+	touchUpdate(tx)
+	return nil
+}
+
+func (r *Reply) BeforeCreate(tx *gorm.DB) error {
+	touchCreate(&r.CreatedAt, &r.UpdatedAt)
+	return nil
+}
+
+func (r *Reply) BeforeUpdate(tx *gorm.DB) error {
+	touchUpdate(tx)
+	return nil
+}
+
+// votes are never updated, so they only have a creation time
+func (v *Vote) BeforeCreate(tx *gorm.DB) error {
+	if v.CreatedAt.IsZero() {
+		v.CreatedAt = DateTime{time.Now()}
+	}
+	return nil
+}
+
+func (u *User) BeforeCreate(tx *gorm.DB) error {
+	touchCreate(&u.CreatedAt, &u.UpdatedAt)
+	return nil
+}
+
+func (u *User) BeforeUpdate(tx *gorm.DB) error {
+	touchUpdate(tx)
+	return nil
+}
+
+func (c *Category) BeforeCreate(tx *gorm.DB) error {
+	touchCreate(&c.CreatedAt, &c.UpdatedAt)
+	return nil
+}
+
+func (c *Category) BeforeUpdate(tx *gorm.DB) error {
+	touchUpdate(tx)
+	return nil
+}
+
+func (k *IdempotencyKey) BeforeCreate(tx *gorm.DB) error {
+	touchCreate(&k.CreatedAt, &k.UpdatedAt)
+	return nil
+}
+
+func (k *IdempotencyKey) BeforeUpdate(tx *gorm.DB) error {
+	touchUpdate(tx)
+	return nil
+}
+
+func (w *Webhook) BeforeCreate(tx *gorm.DB) error {
+	touchCreate(&w.CreatedAt, &w.UpdatedAt)
+	return nil
+}
+
+func (w *Webhook) BeforeUpdate(tx *gorm.DB) error {
+	touchUpdate(tx)
+	return nil
+}
+
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) error {
+	touchCreate(&d.CreatedAt, &d.UpdatedAt)
+	return nil
+}
+
+func (d *WebhookDelivery) BeforeUpdate(tx *gorm.DB) error {
+	touchUpdate(tx)
+	return nil
+}
+
+func (s *Subscription) BeforeCreate(tx *gorm.DB) error {
+	touchCreate(&s.CreatedAt, &s.UpdatedAt)
+	return nil
+}
+
+func (s *Subscription) BeforeUpdate(tx *gorm.DB) error {
+	touchUpdate(tx)
+	return nil
+}
+
+func (n *Notification) BeforeCreate(tx *gorm.DB) error {
+	touchCreate(&n.CreatedAt, &n.UpdatedAt)
+	return nil
+}
+
+func (n *Notification) BeforeUpdate(tx *gorm.DB) error {
+	touchUpdate(tx)
+	return nil
+}
+
+func (p *NotificationPreference) BeforeCreate(tx *gorm.DB) error {
+	touchCreate(&p.CreatedAt, &p.UpdatedAt)
+	return nil
+}
+
+func (p *NotificationPreference) BeforeUpdate(tx *gorm.DB) error {
+	touchUpdate(tx)
+	return nil
+}
+
+func (j *Job) BeforeCreate(tx *gorm.DB) error {
+	touchCreate(&j.CreatedAt, &j.UpdatedAt)
+	return nil
+}
+
+func (j *Job) BeforeUpdate(tx *gorm.DB) error {
+	touchUpdate(tx)
+	return nil
+}
+
+func (t *ScheduledTask) BeforeCreate(tx *gorm.DB) error {
+	touchCreate(&t.CreatedAt, &t.UpdatedAt)
+	return nil
+}
+
+func (t *ScheduledTask) BeforeUpdate(tx *gorm.DB) error {
+	touchUpdate(tx)
+	return nil
+}
+
+func (e *AuditEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = DateTime{time.Now()}
+	}
+	return nil
+}
+
+func (r *Report) BeforeCreate(tx *gorm.DB) error {
+	touchCreate(&r.CreatedAt, &r.UpdatedAt)
+	return nil
+}
+
+func (r *Report) BeforeUpdate(tx *gorm.DB) error {
+	touchUpdate(tx)
+	return nil
+}
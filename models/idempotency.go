@@ -0,0 +1,19 @@
+package models
+
+// IdempotencyKey stores the first response to a request carrying an Idempotency-Key header
+// so retries can be answered without repeating the side effects. StatusCode is 0 while
+// the first request is still being processed; a claim whose LockedUntil has passed was
+// abandoned (e.g. the process died) and can be taken over by a retry.
+type IdempotencyKey struct {
+	Id           uint     `json:"id" gorm:"column:id;type:INT(10) UNSIGNED NOT NULL AUTO_INCREMENT;primaryKey"`
+	Key          string   `json:"key" gorm:"column:idempotency_key;type:varchar(255);not null;uniqueIndex:idx_idempotency_scope_key"`
+	Scope        string   `json:"scope" gorm:"column:scope;type:varchar(64);not null;uniqueIndex:idx_idempotency_scope_key"`
+	RequestHash  string   `json:"request_hash" gorm:"column:request_hash;type:char(64);not null"`
+	StatusCode   int      `json:"status_code" gorm:"column:status_code;default:0"`
+	ContentType  string   `json:"content_type" gorm:"column:content_type;type:varchar(255)"`
+	ResponseBody []byte   `json:"-" gorm:"column:response_body;type:mediumblob"`
+	LockedUntil  DateTime `json:"locked_until" gorm:"column:locked_until;type:DATETIME"`
+	ExpiresAt    DateTime `json:"expires_at" gorm:"column:expires_at;type:DATETIME;index"`
+	CreatedAt    DateTime `json:"created_at" gorm:"column:created_at;type:DATETIME"`
+	UpdatedAt    DateTime `json:"updated_at" gorm:"column:updated_at;type:DATETIME"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "encoding/json"
+
+// Job states. A job is pending until a worker claims it, then running until it
+// succeeds, is scheduled again (pending) or runs out of attempts (failed).
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// JobStatuses lists every job state, in lifecycle order.
+var JobStatuses = []string{JobPending, JobRunning, JobSucceeded, JobFailed}
+
+// Job is a unit of background work, run by the worker pool in package jobs. Kind selects
+// the registered handler and Payload is its JSON argument.
+type Job struct {
+	Id          uint            `json:"id" gorm:"column:id;type:INT(10) UNSIGNED NOT NULL AUTO_INCREMENT;primaryKey"`
+	Kind        string          `json:"kind" gorm:"column:kind;type:varchar(64);not null;index"`
+	Payload     json.RawMessage `json:"payload" gorm:"column:payload;type:mediumtext;not null"`
+	Status      string          `json:"status" gorm:"column:status;type:varchar(20);not null;index:idx_jobs_due"`
+	Attempts    int             `json:"attempts" gorm:"column:attempts;default:0"`
+	MaxAttempts int             `json:"max_attempts" gorm:"column:max_attempts;default:0"`
+	RunAt       DateTime        `json:"run_at" gorm:"column:run_at;type:DATETIME;index:idx_jobs_due"`
+	LockedBy    string          `json:"locked_by,omitempty" gorm:"column:locked_by;type:varchar(128)"`
+	LockedAt    *DateTime       `json:"locked_at,omitempty" gorm:"column:locked_at;type:DATETIME"`
+	LastError   string          `json:"last_error,omitempty" gorm:"column:last_error;type:varchar(1024)"`
+	FinishedAt  *DateTime       `json:"finished_at,omitempty" gorm:"column:finished_at;type:DATETIME"`
+	CreatedAt   DateTime        `json:"created_at" gorm:"column:created_at;type:DATETIME"`
+	UpdatedAt   DateTime        `json:"updated_at" gorm:"column:updated_at;type:DATETIME"`
+}
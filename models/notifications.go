@@ -0,0 +1,42 @@
+package models
+
+import "encoding/json"
+
+// Why a user follows a suggestion.
+const (
+	SubscriptionAuthor    = "author"
+	SubscriptionCommenter = "commenter"
+	SubscriptionVoter     = "voter"
+	SubscriptionManual    = "manual"
+)
+
+// Subscription makes a user receive notifications about a suggestion. Authors, commenters
+// and voters are subscribed automatically; users can also follow or unfollow by hand.
+type Subscription struct {
+	Id           uint     `json:"id" gorm:"column:id;type:INT(10) UNSIGNED NOT NULL AUTO_INCREMENT;primaryKey"`
+	UserId       uint     `json:"user_id" gorm:"column:user_id;type:INT(10) UNSIGNED NOT NULL;uniqueIndex:idx_subscriptions_user_suggestion"`
+	SuggestionId uint     `json:"suggestion_id" gorm:"column:suggestion_id;type:INT(10) UNSIGNED NOT NULL;uniqueIndex:idx_subscriptions_user_suggestion;index"`
+	Reason       string   `json:"reason" gorm:"column:reason;type:varchar(20);not null"`
+	CreatedAt    DateTime `json:"created_at" gorm:"column:created_at;type:DATETIME"`
+	UpdatedAt    DateTime `json:"updated_at" gorm:"column:updated_at;type:DATETIME"`
+}
+
+// Notification types.
+const (
+	NotificationStatusChanged = "suggestion.status_changed"
+	NotificationCommentAdded  = "comment.created"
+	NotificationReplyAdded    = "reply.created"
+)
+
+// Notification is an inbox entry. ReadAt is nil until the user marks it as read.
+type Notification struct {
+	Id           uint            `json:"id" gorm:"column:id;type:INT(10) UNSIGNED NOT NULL AUTO_INCREMENT;primaryKey"`
+	UserId       uint            `json:"user_id" gorm:"column:user_id;type:INT(10) UNSIGNED NOT NULL;index:idx_notifications_inbox"`
+	SuggestionId uint            `json:"suggestion_id" gorm:"column:suggestion_id;type:INT(10) UNSIGNED NOT NULL"`
+	ActorId      uint            `json:"actor_id" gorm:"column:actor_id;type:INT(10) UNSIGNED NOT NULL;default:0"`
+	Type         string          `json:"type" gorm:"column:type;type:varchar(64);not null"`
+	Data         json.RawMessage `json:"data" gorm:"column:data;type:text"`
+	ReadAt       *DateTime       `json:"read_at" gorm:"column:read_at;type:DATETIME;index:idx_notifications_inbox"`
+	CreatedAt    DateTime        `json:"created_at" gorm:"column:created_at;type:DATETIME"`
+	UpdatedAt    DateTime        `json:"updated_at" gorm:"column:updated_at;type:DATETIME"`
+}
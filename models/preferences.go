@@ -0,0 +1,24 @@
+package models
+
+// Digest frequencies.
+const (
+	DigestOff    = "off"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+var DigestFrequencies = []string{DigestOff, DigestDaily, DigestWeekly}
+
+// NotificationPreference holds a user's email settings. The row is created with the
+// defaults the first time it is needed. UnsubscribeToken authenticates the one-click
+// unsubscribe link in every email.
+type NotificationPreference struct {
+	UserId             uint      `json:"user_id" gorm:"column:user_id;type:INT(10) UNSIGNED NOT NULL;primaryKey;autoIncrement:false"`
+	EmailStatusChanges bool      `json:"email_status_changes" gorm:"column:email_status_changes;not null"`
+	EmailReplies       bool      `json:"email_replies" gorm:"column:email_replies;not null"`
+	Digest             string    `json:"digest" gorm:"column:digest;type:varchar(10);not null"`
+	UnsubscribeToken   string    `json:"-" gorm:"column:unsubscribe_token;type:varchar(64);not null;uniqueIndex"`
+	LastDigestAt       *DateTime `json:"last_digest_at" gorm:"column:last_digest_at;type:DATETIME;index"`
+	CreatedAt          DateTime  `json:"created_at" gorm:"column:created_at;type:DATETIME"`
+	UpdatedAt          DateTime  `json:"updated_at" gorm:"column:updated_at;type:DATETIME"`
+}
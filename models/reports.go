@@ -0,0 +1,57 @@
+package models
+
+// Kinds of reported content.
+const (
+	ReportSuggestion = "suggestion"
+	ReportComment    = "comment"
+	ReportReply      = "reply"
+)
+
+// ReportTargets lists every valid Report.TargetType.
+var ReportTargets = []string{ReportSuggestion, ReportComment, ReportReply}
+
+// Why content was reported.
+const (
+	ReasonSpam     = "spam"
+	ReasonAbuse    = "abuse"
+	ReasonOffTopic = "off-topic"
+	ReasonOther    = "other"
+	// ReasonAutomatic marks the report of content held by the content checks. Its
+	// ReporterId is 0.
+	ReasonAutomatic = "automatic"
+)
+
+// ReportReasons lists the reasons users can give.
+var ReportReasons = []string{ReasonSpam, ReasonAbuse, ReasonOffTopic, ReasonOther}
+
+// Report states.
+const (
+	ReportOpen     = "open"
+	ReportResolved = "resolved"
+)
+
+// How a moderator resolved the reports of a target.
+const (
+	ResolutionHidden    = "hidden"
+	ResolutionUnhidden  = "unhidden"
+	ResolutionDeleted   = "deleted"
+	ResolutionDismissed = "dismissed"
+)
+
+// Report flags a suggestion, comment or reply for moderation. It stays open until a
+// moderator acts on the target, which resolves every open report of that target. A user
+// reports a target at most once.
+type Report struct {
+	Id         uint      `json:"id" gorm:"column:id;type:INT(10) UNSIGNED NOT NULL AUTO_INCREMENT;primaryKey"`
+	ReporterId uint      `json:"reporter_id" gorm:"column:reporter_id;type:INT(10) UNSIGNED NOT NULL;uniqueIndex:idx_reports_reporter_target"`
+	TargetType string    `json:"target_type" gorm:"column:target_type;type:varchar(20);not null;uniqueIndex:idx_reports_reporter_target;index:idx_reports_target"`
+	TargetId   uint      `json:"target_id" gorm:"column:target_id;type:INT(10) UNSIGNED NOT NULL;uniqueIndex:idx_reports_reporter_target;index:idx_reports_target"`
+	Reason     string    `json:"reason" gorm:"column:reason;type:varchar(20);not null"`
+	Details    string    `json:"details" gorm:"column:details;type:varchar(1000)"`
+	Status     string    `json:"status" gorm:"column:status;type:varchar(20);not null;index"`
+	Resolution string    `json:"resolution,omitempty" gorm:"column:resolution;type:varchar(20)"`
+	ResolvedBy uint      `json:"resolved_by,omitempty" gorm:"column:resolved_by;type:INT(10) UNSIGNED NOT NULL;default:0"`
+	ResolvedAt *DateTime `json:"resolved_at,omitempty" gorm:"column:resolved_at;type:DATETIME"`
+	CreatedAt  DateTime  `json:"created_at" gorm:"column:created_at;type:DATETIME"`
+	UpdatedAt  DateTime  `json:"updated_at" gorm:"column:updated_at;type:DATETIME"`
+}
@@ -48,6 +48,10 @@ type Suggestion struct {
 	CategoryId uint       `json:"category_id" gorm:"column:category_id;type:INT(10) UNSIGNED NOT NULL;index"`
 	Status     string     `json:"status" gorm:"column:status;type:varchar(20);not null"`
 	UserId     uint       `json:"user_id" gorm:"column:user_id;type:INT(10) UNSIGNED NOT NULL;index"`
+	// TrendingScore ranks recent, well voted suggestions first; recomputed periodically
+	TrendingScore float64 `json:"trending_score" gorm:"column:trending_score;default:0;index"`
+	// HiddenAt is set while moderators (or enough reports) keep the suggestion out of public queries
+	HiddenAt *DateTime `json:"hidden_at,omitempty" gorm:"column:hidden_at;type:DATETIME;index"`
 	// User      User      `json:"user" gorm:"foreignKey:UserId;references:Id"` we can use user_id to get user so we don't need to load user data
 	CreatedAt DateTime       `json:"created_at" gorm:"column:created_at;type:DATETIME"`
 	UpdatedAt DateTime       `json:"updated_at" gorm:"column:updated_at;type:DATETIME"`
@@ -62,6 +66,7 @@ type Comment struct {
 	SuggestionId uint           `json:"suggestion_id" gorm:"column:suggestion_id;type:INT(10) UNSIGNED NOT NULL;index"`
 	Suggestion   *Suggestion    `json:"suggestion" gorm:"foreignKey:SuggestionId;references:Id;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 	Replies      *[]Reply       `json:"replies" gorm:"foreignKey:CommentId;references:Id"`
+	HiddenAt     *DateTime      `json:"hidden_at,omitempty" gorm:"column:hidden_at;type:DATETIME;index"`
 	CreatedAt    DateTime       `json:"created_at" gorm:"column:created_at"`
 	UpdatedAt    DateTime       `json:"updated_at" gorm:"column:updated_at"`
 	DeletedAt    gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"column:deleted_at;index"`
@@ -90,6 +95,7 @@ type Reply struct {
 	Comment   Comment        `json:"comment" gorm:"foreignKey:CommentId;references:Id;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 	UserId    uint           `json:"user_id" gorm:"column:user_id;type:INT(10) UNSIGNED NOT NULL;index"`
 	User      User           `json:"user" gorm:"foreignKey:UserId;references:Id"`
+	HiddenAt  *DateTime      `json:"hidden_at,omitempty" gorm:"column:hidden_at;type:DATETIME;index"`
 	CreatedAt DateTime       `json:"created_at" gorm:"column:created_at"`
 	UpdatedAt DateTime       `json:"updated_at" gorm:"column:updated_at"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"column:deleted_at;index"`
@@ -103,3 +109,29 @@ type Category struct {
 	UpdatedAt   DateTime       `json:"updated_at" gorm:"column:updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"column:deleted_at;index"`
 }
+
+// Vote is one vote cast on a suggestion, kept as a ledger for analytics; Suggestion.Votes
+// stays the running total. Value is +1 or -1 and UserId is 0 for anonymous votes.
+type Vote struct {
+	Id           uint     `json:"id" gorm:"column:id;type:INT(10) UNSIGNED NOT NULL AUTO_INCREMENT;primaryKey"`
+	SuggestionId uint     `json:"suggestion_id" gorm:"column:suggestion_id;type:INT(10) UNSIGNED NOT NULL;index"`
+	UserId       uint     `json:"user_id" gorm:"column:user_id;type:INT(10) UNSIGNED NOT NULL;default:0;index"`
+	Value        int      `json:"value" gorm:"column:value;type:TINYINT NOT NULL"`
+	CreatedAt    DateTime `json:"created_at" gorm:"column:created_at;type:DATETIME;index"`
+}
+
+// Suggestion statuses, in roadmap order.
+const (
+	StatusSuggestion = "suggestion"
+	StatusPlanned    = "planned"
+	StatusInProgress = "in-progress"
+	StatusLive       = "live"
+)
+
+// Statuses lists every valid Suggestion.Status.
+var Statuses = []string{StatusSuggestion, StatusPlanned, StatusInProgress, StatusLive}
+
+// Visible excludes suggestions, comments and replies hidden by moderation, for public queries.
+func Visible(db *gorm.DB) *gorm.DB {
+	return db.Where("hidden_at IS NULL")
+}
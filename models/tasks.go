@@ -0,0 +1,25 @@
+package models
+
+// Scheduled task states. A task is idle until its first run.
+const (
+	TaskIdle      = "idle"
+	TaskRunning   = "running"
+	TaskSucceeded = "succeeded"
+	TaskFailed    = "failed"
+)
+
+// ScheduledTask is the shared state of a task run by package scheduler. The replica that
+// claims a due run holds it until LockedUntil, so each run happens on one replica only.
+type ScheduledTask struct {
+	Name           string    `json:"name" gorm:"column:name;type:varchar(64);primaryKey"`
+	Schedule       string    `json:"schedule" gorm:"column:schedule;type:varchar(128);not null"`
+	Status         string    `json:"status" gorm:"column:status;type:varchar(20);not null"`
+	LastRunAt      *DateTime `json:"last_run_at" gorm:"column:last_run_at;type:DATETIME"`
+	LastDurationMs int64     `json:"last_duration_ms" gorm:"column:last_duration_ms;default:0"`
+	LastError      string    `json:"last_error,omitempty" gorm:"column:last_error;type:varchar(1024)"`
+	NextRunAt      DateTime  `json:"next_run_at" gorm:"column:next_run_at;type:DATETIME"`
+	LockedBy       string    `json:"running_on,omitempty" gorm:"column:locked_by;type:varchar(128)"`
+	LockedUntil    *DateTime `json:"-" gorm:"column:locked_until;type:DATETIME"`
+	CreatedAt      DateTime  `json:"created_at" gorm:"column:created_at;type:DATETIME"`
+	UpdatedAt      DateTime  `json:"updated_at" gorm:"column:updated_at;type:DATETIME"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "strings"
+
+// Truncate cuts value to at most limit bytes, the size of the column it goes into, without
+// splitting a multi-byte character. Invalid UTF-8, e.g. from a webhook receiver's response,
+// is dropped too, since MySQL rejects it.
+func Truncate(value string, limit int) string {
+	if len(value) > limit {
+		value = value[:limit]
+	}
+	return strings.ToValidUTF8(value, "")
+}
@@ -0,0 +1,27 @@
+package models
+
+import "testing"
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		limit int
+		want  string
+	}{
+		{"shorter than the limit", "timeout", 10, "timeout"},
+		{"exactly the limit", "timeout", 7, "timeout"},
+		{"cut at the limit", "connection refused", 10, "connection"},
+		{"does not split a character", "café au lait", 4, "caf"},
+		{"keeps a character that fits", "café au lait", 5, "café"},
+		{"drops invalid bytes", "ok\xff\xfe!", 10, "ok!"},
+		{"empty", "", 10, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Truncate(tt.value, tt.limit); got != tt.want {
+				t.Errorf("Truncate(%q, %d) = %q, want %q", tt.value, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// StringList is stored as a comma separated column and rendered as a JSON array.
+type StringList []string
+
+func (l *StringList) Scan(value interface{}) error {
+	var raw string
+	switch v := value.(type) {
+	case nil:
+	case []byte:
+		raw = string(v)
+	case string:
+		raw = v
+	default:
+		return fmt.Errorf("cannot scan type %T into StringList", value)
+	}
+	*l = StringList{}
+	for _, item := range strings.Split(raw, ",") {
+		if item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+func (l StringList) Value() (driver.Value, error) {
+	return strings.Join(l, ","), nil
+}
+
+// WebhookAllEvents subscribes a webhook to every event type.
+const WebhookAllEvents = "*"
+
+// Webhook is an outgoing webhook subscription. Payloads are signed with Secret, which is
+// only returned when the webhook is created.
+type Webhook struct {
+	Id          uint       `json:"id" gorm:"column:id;type:INT(10) UNSIGNED NOT NULL AUTO_INCREMENT;primaryKey"`
+	Url         string     `json:"url" gorm:"column:url;type:varchar(2048);not null"`
+	Secret      string     `json:"-" gorm:"column:secret;type:varchar(255);not null"`
+	Events      StringList `json:"events" gorm:"column:events;type:varchar(1024);not null"`
+	Description string     `json:"description" gorm:"column:description;type:varchar(255)"`
+	Active      bool       `json:"active" gorm:"column:active;not null;default:true"`
+	CreatedAt   DateTime   `json:"created_at" gorm:"column:created_at;type:DATETIME"`
+	UpdatedAt   DateTime   `json:"updated_at" gorm:"column:updated_at;type:DATETIME"`
+}
+
+// Subscribed reports whether the webhook wants events of eventType.
+func (w Webhook) Subscribed(eventType string) bool {
+	for _, event := range w.Events {
+		if event == WebhookAllEvents || event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook delivery states.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is one event sent (or to be sent) to a webhook, kept as the delivery log.
+// Failed attempts are retried at NextAttemptAt until the attempts run out.
+type WebhookDelivery struct {
+	Id             uint            `json:"id" gorm:"column:id;type:INT(10) UNSIGNED NOT NULL AUTO_INCREMENT;primaryKey"`
+	WebhookId      uint            `json:"webhook_id" gorm:"column:webhook_id;type:INT(10) UNSIGNED NOT NULL;index"`
+	Webhook        *Webhook        `json:"-" gorm:"foreignKey:WebhookId;references:Id;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	EventType      string          `json:"event_type" gorm:"column:event_type;type:varchar(64);not null"`
+	Payload        json.RawMessage `json:"payload" gorm:"column:payload;type:mediumtext;not null"`
+	Status         string          `json:"status" gorm:"column:status;type:varchar(20);not null;index:idx_webhook_deliveries_due"`
+	Attempts       int             `json:"attempts" gorm:"column:attempts;default:0"`
+	ResponseStatus int             `json:"response_status" gorm:"column:response_status;default:0"`
+	ResponseBody   string          `json:"response_body" gorm:"column:response_body;type:text"`
+	Error          string          `json:"error" gorm:"column:error;type:varchar(1024)"`
+	RedeliveryOf   uint            `json:"redelivery_of,omitempty" gorm:"column:redelivery_of;type:INT(10) UNSIGNED NOT NULL;default:0"`
+	NextAttemptAt  DateTime        `json:"next_attempt_at" gorm:"column:next_attempt_at;type:DATETIME;index:idx_webhook_deliveries_due"`
+	DeliveredAt    DateTime        `json:"delivered_at" gorm:"column:delivered_at;type:DATETIME"`
+	CreatedAt      DateTime        `json:"created_at" gorm:"column:created_at;type:DATETIME"`
+	UpdatedAt      DateTime        `json:"updated_at" gorm:"column:updated_at;type:DATETIME"`
+}
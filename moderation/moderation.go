@@ -0,0 +1,423 @@
+// Package moderation handles reports of abusive suggestions, comments and replies: filing
+// them, hiding content that gathered enough reports, the moderation queue and the actions
+// moderators take on it. Hidden content keeps its rows but is left out of public queries
+// (models.Visible).
+package moderation
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"feedback-io.backend/audit"
+	"feedback-io.backend/cache"
+	"feedback-io.backend/config"
+	"feedback-io.backend/models"
+	"feedback-io.backend/suggestions"
+	"gorm.io/gorm"
+)
+
+// Actions a moderator can take on reported content. Each resolves the open reports of
+// the content.
+const (
+	Hide    = "hide"
+	Unhide  = "unhide"
+	Delete  = "delete"
+	Dismiss = "dismiss"
+)
+
+// Actions lists the accepted moderation actions.
+var Actions = []string{Hide, Unhide, Delete, Dismiss}
+
+// detailsLimit is the size of the reports.details column.
+const detailsLimit = 1000
+
+var resolutions = map[string]string{
+	Hide:    models.ResolutionHidden,
+	Unhide:  models.ResolutionUnhidden,
+	Delete:  models.ResolutionDeleted,
+	Dismiss: models.ResolutionDismissed,
+}
+
+var auditActions = map[string]string{
+	Hide:    audit.ModerationHide,
+	Unhide:  audit.ModerationUnhide,
+	Delete:  audit.ModerationDelete,
+	Dismiss: audit.ModerationDismiss,
+}
+
+// Item is reported content with its reports. Deleted is set when the content was deleted
+// by other means while its reports were open.
+type Item struct {
+	TargetType   string           `json:"target_type"`
+	TargetId     uint             `json:"target_id"`
+	SuggestionId uint             `json:"suggestion_id"`
+	Title        string           `json:"title,omitempty"`
+	Content      string           `json:"content"`
+	AuthorId     uint             `json:"author_id"`
+	HiddenAt     *models.DateTime `json:"hidden_at"`
+	Deleted      bool             `json:"deleted,omitempty"`
+	Reports      []models.Report  `json:"reports"`
+}
+
+// content is the moderated part of a suggestion, comment or reply.
+type content struct {
+	Id           uint
+	Title        string
+	Content      string
+	UserId       uint
+	SuggestionId uint
+	HiddenAt     *models.DateTime
+}
+
+type table struct {
+	name    string
+	columns string
+	joins   string
+}
+
+var tables = map[string]table{
+	models.ReportSuggestion: {
+		name:    "suggestions",
+		columns: "suggestions.id, suggestions.title, suggestions.content, suggestions.user_id, suggestions.id AS suggestion_id, suggestions.hidden_at",
+	},
+	models.ReportComment: {
+		name:    "comments",
+		columns: "comments.id, comments.content, comments.user_id, comments.suggestion_id, comments.hidden_at",
+	},
+	models.ReportReply: {
+		name:    "replies",
+		columns: "replies.id, replies.content, replies.user_id, comments.suggestion_id, replies.hidden_at",
+		joins:   "JOIN comments ON comments.id = replies.comment_id",
+	},
+}
+
+// load returns the content of the given ids that wasn't deleted, hidden or not.
+func load(db *gorm.DB, targetType string, ids []uint) (map[uint]content, error) {
+	t := tables[targetType]
+	query := db.Table(t.name).Select(t.columns).
+		Where(t.name+".id IN ? AND "+t.name+".deleted_at IS NULL", ids)
+	if t.joins != "" {
+		query = query.Joins(t.joins)
+	}
+
+	var rows []content
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	found := make(map[uint]content, len(rows))
+	for _, row := range rows {
+		found[row.Id] = row
+	}
+	return found, nil
+}
+
+// find returns one piece of content, or gorm.ErrRecordNotFound.
+func find(db *gorm.DB, targetType string, id uint) (content, error) {
+	found, err := load(db, targetType, []uint{id})
+	if err != nil {
+		return content{}, err
+	}
+	c, ok := found[id]
+	if !ok {
+		return c, gorm.ErrRecordNotFound
+	}
+	return c, nil
+}
+
+// setHidden hides or unhides content and reports whether that changed anything.
+func setHidden(db *gorm.DB, targetType string, id uint, hiddenAt *models.DateTime) (bool, error) {
+	query := db.Table(tables[targetType].name).Where("id = ?", id)
+	if hiddenAt != nil {
+		query = query.Where("hidden_at IS NULL")
+	} else {
+		query = query.Where("hidden_at IS NOT NULL")
+	}
+	result := query.UpdateColumn("hidden_at", hiddenAt)
+	return result.RowsAffected > 0, result.Error
+}
+
+// autoHide is when File hides reported content: once Threshold open reports (0 never)
+// come from accounts at least MinAge old.
+type autoHide struct {
+	Threshold int
+	MinAge    time.Duration
+}
+
+func autoHideFromEnv() autoHide {
+	return autoHide{
+		Threshold: config.GetEnvInt("MODERATION_AUTO_HIDE_REPORTS", 0),
+		MinAge:    config.GetEnvDuration("MODERATION_REPORTER_MIN_AGE", 72*time.Hour),
+	}
+}
+
+// registeredBefore is the latest creation time of an account whose reports count at now.
+func (p autoHide) registeredBefore(now time.Time) models.DateTime {
+	return models.DateTime{Time: now.Add(-p.MinAge)}
+}
+
+// reached reports whether open counted reports hide the content.
+func (p autoHide) reached(open int64) bool {
+	return p.Threshold > 0 && open >= int64(p.Threshold)
+}
+
+// countedReports selects the open reports of a target that count towards hiding it: those
+// of existing accounts created by registeredBefore. Automatic holds (reporter 0) have no
+// account and never count.
+func countedReports(tx *gorm.DB, targetType string, id uint, registeredBefore models.DateTime) *gorm.DB {
+	return tx.Model(&models.Report{}).
+		Joins("JOIN users ON users.id = reports.reporter_id").
+		Where("reports.target_type = ? AND reports.target_id = ? AND reports.status = ?", targetType, id, models.ReportOpen).
+		Where("users.created_at <= ? AND users.deleted_at IS NULL", registeredBefore)
+}
+
+// File records report, by its ReporterId, and hides the target once it has
+// MODERATION_AUTO_HIDE_REPORTS (off by default) open reports from accounts older than
+// MODERATION_REPORTER_MIN_AGE (72h by default), so freshly registered accounts can't hide
+// content together. It returns gorm.ErrRecordNotFound for a missing target and
+// gorm.ErrDuplicatedKey when the reporter already reported it.
+func File(ctx context.Context, db *gorm.DB, report *models.Report) (hidden bool, err error) {
+	policy := autoHideFromEnv()
+
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := find(tx, report.TargetType, report.TargetId); err != nil {
+			return err
+		}
+		report.Status = models.ReportOpen
+		if err := tx.Create(report).Error; err != nil {
+			return err
+		}
+		if policy.Threshold <= 0 {
+			return nil
+		}
+
+		var open int64
+		if err := countedReports(tx, report.TargetType, report.TargetId, policy.registeredBefore(time.Now())).
+			Count(&open).Error; err != nil {
+			return err
+		}
+		if !policy.reached(open) {
+			return nil
+		}
+
+		now := models.DateTime{Time: time.Now()}
+		if hidden, err = setHidden(tx, report.TargetType, report.TargetId, &now); err != nil || !hidden {
+			return err
+		}
+		return audit.Record(tx, audit.System, audit.Event{
+			Action:     audit.ModerationAutoHide,
+			TargetType: report.TargetType,
+			TargetId:   report.TargetId,
+			After:      map[string]any{"hidden_at": now, "open_reports": open},
+		})
+	})
+	if err == nil && hidden {
+		cache.Invalidate(ctx, cache.SuggestionsPrefix)
+	}
+	return hidden, err
+}
+
+// Hold files the report of content the content checks flagged, so it lands in the
+// moderation queue. The content must have been created hidden, in the same transaction tx.
+// reasons are what the checks found.
+func Hold(tx *gorm.DB, targetType string, id uint, reasons []string) error {
+	details := models.Truncate(strings.Join(reasons, "; "), detailsLimit)
+	report := models.Report{
+		TargetType: targetType,
+		TargetId:   id,
+		Reason:     models.ReasonAutomatic,
+		Details:    details,
+		Status:     models.ReportOpen,
+	}
+	if err := tx.Create(&report).Error; err != nil {
+		return err
+	}
+	return audit.Record(tx, audit.System, audit.Event{
+		Action:     audit.ModerationAutoHold,
+		TargetType: targetType,
+		TargetId:   id,
+		After:      map[string]any{"reasons": reasons},
+	})
+}
+
+// Queue lists content with open reports, longest waiting first, optionally of one
+// targetType. It returns one page and the number of items in the queue.
+func Queue(ctx context.Context, db *gorm.DB, targetType string, offset, limit int) ([]Item, int64, error) {
+	db = db.WithContext(ctx)
+	open := func() *gorm.DB {
+		query := db.Model(&models.Report{}).Where("status = ?", models.ReportOpen)
+		if targetType != "" {
+			query = query.Where("target_type = ?", targetType)
+		}
+		return query
+	}
+
+	var count int64
+	if err := db.Table("(?) AS queue", open().Select("target_type, target_id").Group("target_type, target_id")).
+		Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var keys []struct {
+		TargetType string
+		TargetId   uint
+	}
+	if err := open().
+		Select("target_type, target_id, MIN(created_at) AS first_reported_at").
+		Group("target_type, target_id").
+		Order("first_reported_at, target_type, target_id").
+		Limit(limit).Offset(offset).
+		Scan(&keys).Error; err != nil {
+		return nil, 0, err
+	}
+
+	items := make([]Item, 0, len(keys))
+	ids := map[string][]uint{}
+	for _, key := range keys {
+		items = append(items, Item{TargetType: key.TargetType, TargetId: key.TargetId, Reports: []models.Report{}})
+		ids[key.TargetType] = append(ids[key.TargetType], key.TargetId)
+	}
+
+	for kind, targetIDs := range ids {
+		found, err := load(db, kind, targetIDs)
+		if err != nil {
+			return nil, 0, err
+		}
+		var reports []models.Report
+		if err := open().Where("target_type = ? AND target_id IN ?", kind, targetIDs).Order("id").
+			Find(&reports).Error; err != nil {
+			return nil, 0, err
+		}
+
+		for i := range items {
+			item := &items[i]
+			if item.TargetType != kind {
+				continue
+			}
+			if c, ok := found[item.TargetId]; ok {
+				fill(item, c)
+			} else {
+				item.Deleted = true
+			}
+			for _, report := range reports {
+				if report.TargetId == item.TargetId {
+					item.Reports = append(item.Reports, report)
+				}
+			}
+		}
+	}
+	return items, count, nil
+}
+
+func fill(item *Item, c content) {
+	item.SuggestionId = c.SuggestionId
+	item.Title = c.Title
+	item.Content = c.Content
+	item.AuthorId = c.UserId
+	item.HiddenAt = c.HiddenAt
+}
+
+// Apply takes a moderation action on content and resolves its open reports, recording it
+// in the audit log. Only Dismiss applies to content that was deleted in the meantime. It
+// returns gorm.ErrRecordNotFound when there is nothing to act on.
+func Apply(ctx context.Context, db *gorm.DB, actor audit.Actor, targetType string, id uint, action, note string) (Item, error) {
+	item := Item{TargetType: targetType, TargetId: id, Reports: []models.Report{}}
+	now := models.DateTime{Time: time.Now()}
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		c, err := find(tx, targetType, id)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound) && action == Dismiss:
+			item.Deleted = true
+		case err != nil:
+			return err
+		default:
+			fill(&item, c)
+		}
+
+		if err := tx.Where("target_type = ? AND target_id = ? AND status = ?", targetType, id, models.ReportOpen).
+			Order("id").Find(&item.Reports).Error; err != nil {
+			return err
+		}
+		if item.Deleted && len(item.Reports) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		before := map[string]any{"hidden_at": item.HiddenAt}
+		after := map[string]any{"note": note, "reports_resolved": len(item.Reports)}
+		switch action {
+		case Hide:
+			if item.HiddenAt == nil {
+				if _, err := setHidden(tx, targetType, id, &now); err != nil {
+					return err
+				}
+				item.HiddenAt = &now
+			}
+			after["hidden_at"] = item.HiddenAt
+		case Unhide:
+			if _, err := setHidden(tx, targetType, id, nil); err != nil {
+				return err
+			}
+			item.HiddenAt = nil
+			after["hidden_at"] = nil
+		case Delete:
+			if err := softDelete(tx, targetType, id, now); err != nil {
+				return err
+			}
+			item.Deleted = true
+			after["deleted_at"] = now
+		}
+
+		if len(item.Reports) > 0 {
+			resolution := map[string]any{
+				"status":      models.ReportResolved,
+				"resolution":  resolutions[action],
+				"resolved_by": actor.UserId,
+				"resolved_at": now,
+			}
+			if err := tx.Model(&models.Report{}).
+				Where("target_type = ? AND target_id = ? AND status = ?", targetType, id, models.ReportOpen).
+				Updates(resolution).Error; err != nil {
+				return err
+			}
+			for i := range item.Reports {
+				item.Reports[i].Status = models.ReportResolved
+				item.Reports[i].Resolution = resolutions[action]
+				item.Reports[i].ResolvedBy = actor.UserId
+				item.Reports[i].ResolvedAt = &now
+			}
+		}
+
+		return audit.Record(tx, actor, audit.Event{
+			Action:     auditActions[action],
+			TargetType: targetType,
+			TargetId:   id,
+			Before:     before,
+			After:      after,
+		})
+	})
+	if err != nil {
+		return item, err
+	}
+
+	if action != Dismiss {
+		cache.Invalidate(ctx, cache.SuggestionsPrefix)
+	}
+	return item, nil
+}
+
+// softDelete deletes content like its owner would, with the comments and replies below it.
+func softDelete(tx *gorm.DB, targetType string, id uint, now models.DateTime) error {
+	switch targetType {
+	case models.ReportSuggestion:
+		return suggestions.SoftDelete(tx, id, now)
+	case models.ReportComment:
+		if err := tx.Model(&models.Reply{}).Where("comment_id = ?", id).Update("deleted_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Comment{}).Where("id = ?", id).Update("deleted_at", now).Error
+	default:
+		return tx.Model(&models.Reply{}).Where("id = ?", id).Update("deleted_at", now).Error
+	}
+}
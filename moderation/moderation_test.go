@@ -0,0 +1,80 @@
+package moderation
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"feedback-io.backend/models"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestAutoHideFromEnv(t *testing.T) {
+	if got, want := autoHideFromEnv(), (autoHide{Threshold: 0, MinAge: 72 * time.Hour}); got != want {
+		t.Errorf("defaults = %+v, want %+v", got, want)
+	}
+
+	t.Setenv("MODERATION_AUTO_HIDE_REPORTS", "3")
+	t.Setenv("MODERATION_REPORTER_MIN_AGE", "24h")
+	if got, want := autoHideFromEnv(), (autoHide{Threshold: 3, MinAge: 24 * time.Hour}); got != want {
+		t.Errorf("from env = %+v, want %+v", got, want)
+	}
+}
+
+func TestAutoHideReached(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		open      int64
+		want      bool
+	}{
+		{"off by default", 0, 100, false},
+		{"negative is off", -1, 100, false},
+		{"below the threshold", 3, 2, false},
+		{"at the threshold", 3, 3, true},
+		{"above the threshold", 3, 4, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (autoHide{Threshold: tt.threshold}).reached(tt.open); got != tt.want {
+				t.Errorf("reached(%d) with threshold %d = %v, want %v", tt.open, tt.threshold, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAutoHideRegisteredBefore(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	got := autoHide{MinAge: 72 * time.Hour}.registeredBefore(now)
+	if want := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC); !got.Time.Equal(want) {
+		t.Errorf("registeredBefore = %s, want %s", got.Time, want)
+	}
+}
+
+func TestCountedReports(t *testing.T) {
+	// a dry run renders the SQL without connecting
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "user@tcp(127.0.0.1:3306)/test", SkipInitializeWithVersion: true}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	registeredBefore := models.DateTime{Time: time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)}
+	query := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		var n int64
+		return countedReports(tx, models.ReportSuggestion, 7, registeredBefore).Count(&n)
+	})
+
+	for _, want := range []string{
+		// only reporters with an account: automatic holds (reporter 0) don't count
+		"JOIN users ON users.id = reports.reporter_id",
+		"reports.target_type = 'suggestion' AND reports.target_id = 7 AND reports.status = 'open'",
+		"users.created_at <= '2026-10-16 12:00:00'",
+		"users.deleted_at IS NULL",
+	} {
+		if !strings.Contains(query, want) {
+			t.Errorf("query %q does not contain %q", query, want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"feedback-io.backend/config"
+	"feedback-io.backend/jobs"
+	"feedback-io.backend/mailer"
+	"feedback-io.backend/models"
+	"gorm.io/gorm"
+)
+
+// digestPeriods is how often each digest frequency is sent.
+var digestPeriods = map[string]time.Duration{
+	models.DigestDaily:  24 * time.Hour,
+	models.DigestWeekly: 7 * 24 * time.Hour,
+}
+
+// digestLimit caps the notifications listed in one digest.
+const digestLimit = 50
+
+// SendDueDigests queues a digest email of unread notifications to every user whose daily or
+// weekly period has elapsed, and returns how many were queued. Safe to run on several
+// replicas at once: each user's period is claimed in the transaction that queues their
+// digest.
+func SendDueDigests(ctx context.Context) (int, error) {
+	if mailer.Default == nil {
+		return 0, nil
+	}
+	db := config.DB.WithContext(ctx)
+
+	sent := 0
+	for frequency, period := range digestPeriods {
+		cutoff := models.DateTime{Time: time.Now().Add(-period)}
+
+		var batch []models.NotificationPreference
+		err := db.Where("digest = ? AND (last_digest_at IS NULL OR last_digest_at <= ?)", frequency, cutoff).
+			FindInBatches(&batch, 100, func(tx *gorm.DB, _ int) error {
+				for _, preferences := range batch {
+					if ctx.Err() != nil {
+						return ctx.Err()
+					}
+					ok, err := sendDigest(ctx, db, preferences, frequency, period, cutoff)
+					if err != nil {
+						slog.Error("failed to send digest", "user_id", preferences.UserId, "error", err)
+						continue
+					}
+					if ok {
+						sent++
+					}
+				}
+				return nil
+			}).Error
+		if err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+func sendDigest(ctx context.Context, db *gorm.DB, preferences models.NotificationPreference, frequency string, period time.Duration, cutoff models.DateTime) (bool, error) {
+	now := time.Now()
+
+	since := now.Add(-period)
+	if preferences.LastDigestAt != nil {
+		since = preferences.LastDigestAt.Time
+	}
+
+	var unread []models.Notification
+	if err := db.Where("user_id = ? AND read_at IS NULL AND created_at > ?", preferences.UserId, models.DateTime{Time: since}).
+		Order("id DESC").
+		Limit(digestLimit).
+		Find(&unread).Error; err != nil {
+		return false, err
+	}
+	if len(unread) == 0 {
+		return false, nil
+	}
+
+	var user models.User
+	if err := db.First(&user, preferences.UserId).Error; err != nil || user.Email == "" {
+		return false, err
+	}
+
+	items := make([]digestItem, 0, len(unread))
+	for _, notification := range unread {
+		items = append(items, digestItem{
+			Summary: summarize(notification),
+			URL:     suggestionURL(notification.SuggestionId),
+		})
+	}
+
+	message, err := mailer.Render("digest", user.Email, emailData{
+		Name:           displayName(user),
+		URL:            appURL(),
+		UnsubscribeURL: unsubscribeURL(preferences.UnsubscribeToken),
+		Frequency:      frequency,
+		Items:          items,
+	})
+	if err != nil {
+		return false, err
+	}
+	withUnsubscribe(&message, preferences)
+
+	// The period is claimed in the transaction that queues the email, so a failure
+	// before the commit leaves the digest due, and only one replica's claim succeeds.
+	claimed := false
+	err = db.Transaction(func(tx *gorm.DB) error {
+		claim := tx.Model(&models.NotificationPreference{}).
+			Where("user_id = ? AND (last_digest_at IS NULL OR last_digest_at <= ?)", preferences.UserId, cutoff).
+			Update("last_digest_at", models.DateTime{Time: now})
+		if claim.Error != nil || claim.RowsAffected == 0 {
+			return claim.Error
+		}
+		claimed = true
+		_, err := jobs.Enqueue(tx, JobSendEmail, message)
+		return err
+	})
+	return claimed && err == nil, err
+}
+
+// summarize describes a notification in one line.
+func summarize(notification models.Notification) string {
+	var data struct {
+		Title string `json:"suggestion_title"`
+		To    string `json:"to"`
+	}
+	_ = json.Unmarshal(notification.Data, &data)
+
+	switch notification.Type {
+	case models.NotificationStatusChanged:
+		return fmt.Sprintf("%q is now %s", data.Title, data.To)
+	case models.NotificationCommentAdded:
+		return fmt.Sprintf("New comment on %q", data.Title)
+	case models.NotificationReplyAdded:
+		return fmt.Sprintf("New reply to your comment on %q", data.Title)
+	}
+	return fmt.Sprintf("Update on %q", data.Title)
+}
@@ -0,0 +1,197 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+
+	"feedback-io.backend/config"
+	"feedback-io.backend/jobs"
+	"feedback-io.backend/logger"
+	"feedback-io.backend/mailer"
+	"feedback-io.backend/models"
+	"gorm.io/gorm"
+)
+
+// JobSendEmail is the job kind that sends one rendered mailer.Message.
+const JobSendEmail = "email.send"
+
+func init() {
+	jobs.Register(JobSendEmail, sendEmail)
+}
+
+// emailData feeds every template in mailer/templates; each email uses a subset.
+type emailData struct {
+	Name           string
+	Title          string
+	URL            string
+	UnsubscribeURL string
+
+	// status_changed
+	From   string
+	Status string
+
+	// comment_reply
+	Author  string
+	Content string
+
+	// digest
+	Frequency string
+	Items     []digestItem
+}
+
+type digestItem struct {
+	Summary string
+	URL     string
+}
+
+// EmailStatusChange tells the author that their suggestion changed status, unless they
+// changed it themselves, opted out or get digests instead.
+func EmailStatusChange(ctx context.Context, suggestion models.Suggestion, from string, actorID uint) {
+	if mailer.Default == nil || suggestion.UserId == 0 || suggestion.UserId == actorID {
+		return
+	}
+	log := logger.FromContext(ctx).With("suggestion_id", suggestion.Id, "user_id", suggestion.UserId)
+
+	user, preferences, err := recipient(config.DB.WithContext(ctx), suggestion.UserId)
+	if err != nil || !preferences.EmailStatusChanges || preferences.Digest != models.DigestOff {
+		logRecipientError(log, err)
+		return
+	}
+
+	message, err := mailer.Render("status_changed", user.Email, emailData{
+		Name:           displayName(user),
+		Title:          suggestion.Title,
+		URL:            suggestionURL(suggestion.Id),
+		UnsubscribeURL: unsubscribeURL(preferences.UnsubscribeToken),
+		From:           from,
+		Status:         suggestion.Status,
+	})
+	if err != nil {
+		log.Error("failed to render status change email", "error", err)
+		return
+	}
+	enqueue(ctx, log, message, preferences)
+}
+
+// EmailReply tells the author of comment that someone replied, unless they replied to
+// themselves, opted out or get digests instead.
+func EmailReply(ctx context.Context, suggestion models.Suggestion, comment models.Comment, reply models.Reply) {
+	if mailer.Default == nil || comment.UserId == 0 || comment.UserId == reply.UserId {
+		return
+	}
+	log := logger.FromContext(ctx).With("suggestion_id", suggestion.Id, "user_id", comment.UserId)
+	db := config.DB.WithContext(ctx)
+
+	user, preferences, err := recipient(db, comment.UserId)
+	if err != nil || !preferences.EmailReplies || preferences.Digest != models.DigestOff {
+		logRecipientError(log, err)
+		return
+	}
+
+	author := "Someone"
+	var replier models.User
+	if err := db.First(&replier, reply.UserId).Error; err == nil {
+		author = displayName(replier)
+	}
+
+	message, err := mailer.Render("comment_reply", user.Email, emailData{
+		Name:           displayName(user),
+		Title:          suggestion.Title,
+		URL:            suggestionURL(suggestion.Id),
+		UnsubscribeURL: unsubscribeURL(preferences.UnsubscribeToken),
+		Author:         author,
+		Content:        reply.Content,
+	})
+	if err != nil {
+		log.Error("failed to render reply email", "error", err)
+		return
+	}
+	enqueue(ctx, log, message, preferences)
+}
+
+// recipient loads a user with an email address and their preferences.
+func recipient(db *gorm.DB, userID uint) (models.User, models.NotificationPreference, error) {
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		return user, models.NotificationPreference{}, err
+	}
+	if user.Email == "" {
+		return user, models.NotificationPreference{}, gorm.ErrRecordNotFound
+	}
+	preferences, err := Preferences(db, userID)
+	return user, preferences, err
+}
+
+// logRecipientError ignores users that don't exist or have no address.
+func logRecipientError(log *slog.Logger, err error) {
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Error("failed to load email recipient", "error", err)
+	}
+}
+
+// enqueue queues the email so a slow or unavailable mail relay neither delays the
+// response nor loses the message: the job is retried until the relay accepts it.
+func enqueue(ctx context.Context, log *slog.Logger, message mailer.Message, preferences models.NotificationPreference) {
+	withUnsubscribe(&message, preferences)
+	if _, err := jobs.Enqueue(config.DB.WithContext(ctx), JobSendEmail, message); err != nil {
+		log.Error("failed to queue email", "subject", message.Subject, "error", err)
+	}
+}
+
+func sendEmail(ctx context.Context, payload json.RawMessage) error {
+	if mailer.Default == nil {
+		return nil
+	}
+	var message mailer.Message
+	if err := json.Unmarshal(payload, &message); err != nil {
+		return jobs.Permanent(err)
+	}
+	return mailer.Send(ctx, message)
+}
+
+// withUnsubscribe adds the RFC 8058 one-click unsubscribe headers.
+func withUnsubscribe(message *mailer.Message, preferences models.NotificationPreference) {
+	if message.Headers == nil {
+		message.Headers = map[string]string{}
+	}
+	message.Headers["List-Unsubscribe"] = "<" + unsubscribeURL(preferences.UnsubscribeToken) + ">"
+	message.Headers["List-Unsubscribe-Post"] = "List-Unsubscribe=One-Click"
+}
+
+func displayName(user models.User) string {
+	if user.FirstName != "" {
+		return user.FirstName
+	}
+	return user.Username
+}
+
+// appURL is the frontend, linked from emails.
+func appURL() string {
+	return envURL("APP_URL", "https://feedback-io.netlify.app")
+}
+
+// publicAPIURL is where this API is reachable from the internet, for unsubscribe links.
+func publicAPIURL() string {
+	return envURL("PUBLIC_API_URL", "http://localhost:"+os.Getenv("PORT"))
+}
+
+func envURL(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return strings.TrimSuffix(value, "/")
+	}
+	return fallback
+}
+
+func suggestionURL(id uint) string {
+	return fmt.Sprintf("%s/suggestions/%d", appURL(), id)
+}
+
+func unsubscribeURL(token string) string {
+	return publicAPIURL() + "/api/v1/unsubscribe?token=" + url.QueryEscape(token)
+}
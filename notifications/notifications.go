@@ -0,0 +1,91 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+
+	"feedback-io.backend/config"
+	"feedback-io.backend/logger"
+	"feedback-io.backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Subscribe makes userID follow suggestionID. Following twice is a no-op and keeps the
+// original reason.
+func Subscribe(db *gorm.DB, userID, suggestionID uint, reason string) (models.Subscription, error) {
+	var subscription models.Subscription
+	if err := insert(db, userID, suggestionID, reason); err != nil {
+		return subscription, err
+	}
+	err := db.Where("user_id = ? AND suggestion_id = ?", userID, suggestionID).First(&subscription).Error
+	return subscription, err
+}
+
+// Follow subscribes a participant after their change was committed. Anonymous users
+// (id 0) are skipped and failures are only logged: they must not fail the request.
+func Follow(ctx context.Context, userID, suggestionID uint, reason string) {
+	if userID == 0 {
+		return
+	}
+	if err := insert(config.DB.WithContext(ctx), userID, suggestionID, reason); err != nil {
+		logger.FromContext(ctx).Error("failed to subscribe user", "user_id", userID, "suggestion_id", suggestionID, "error", err)
+	}
+}
+
+func insert(db *gorm.DB, userID, suggestionID uint, reason string) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.Subscription{UserId: userID, SuggestionId: suggestionID, Reason: reason}).Error
+}
+
+// Notify adds a notification to the inbox of every subscriber of suggestionID except
+// the actor who caused it. Like Follow it only logs failures.
+func Notify(ctx context.Context, suggestionID, actorID uint, notificationType string, data any) {
+	log := logger.FromContext(ctx).With("suggestion_id", suggestionID, "type", notificationType)
+	db := config.DB.WithContext(ctx)
+
+	var subscribers []uint
+	if err := db.Model(&models.Subscription{}).
+		Where("suggestion_id = ? AND user_id <> ?", suggestionID, actorID).
+		Pluck("user_id", &subscribers).Error; err != nil {
+		log.Error("failed to load subscribers", "error", err)
+		return
+	}
+	deliver(ctx, subscribers, suggestionID, actorID, notificationType, data)
+}
+
+// NotifyUser adds a notification to the inbox of one user, e.g. the author of a comment
+// that was replied to. The actor is never notified of their own change.
+func NotifyUser(ctx context.Context, userID, suggestionID, actorID uint, notificationType string, data any) {
+	if userID == 0 || userID == actorID {
+		return
+	}
+	deliver(ctx, []uint{userID}, suggestionID, actorID, notificationType, data)
+}
+
+func deliver(ctx context.Context, recipients []uint, suggestionID, actorID uint, notificationType string, data any) {
+	if len(recipients) == 0 {
+		return
+	}
+	log := logger.FromContext(ctx).With("suggestion_id", suggestionID, "type", notificationType)
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Error("failed to encode notification", "error", err)
+		return
+	}
+
+	notifications := make([]models.Notification, 0, len(recipients))
+	for _, userID := range recipients {
+		notifications = append(notifications, models.Notification{
+			UserId:       userID,
+			SuggestionId: suggestionID,
+			ActorId:      actorID,
+			Type:         notificationType,
+			Data:         payload,
+		})
+	}
+	if err := config.DB.WithContext(ctx).CreateInBatches(&notifications, 500).Error; err != nil {
+		log.Error("failed to create notifications", "error", err)
+	}
+}
@@ -0,0 +1,68 @@
+package notifications
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+
+	"feedback-io.backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Preferences returns the email preferences of userID, creating them with the defaults:
+// instant emails on, digest off.
+func Preferences(db *gorm.DB, userID uint) (models.NotificationPreference, error) {
+	var preferences models.NotificationPreference
+	err := db.Where("user_id = ?", userID).First(&preferences).Error
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return preferences, err
+	}
+
+	defaults := models.NotificationPreference{
+		UserId:             userID,
+		EmailStatusChanges: true,
+		EmailReplies:       true,
+		Digest:             models.DigestOff,
+		UnsubscribeToken:   newToken(),
+	}
+	// a concurrent request may have created them first
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&defaults).Error; err != nil {
+		return preferences, err
+	}
+	err = db.Where("user_id = ?", userID).First(&preferences).Error
+	return preferences, err
+}
+
+// ByToken returns the preferences with the unsubscribe token from an email. An unknown
+// token returns gorm.ErrRecordNotFound.
+func ByToken(db *gorm.DB, token string) (models.NotificationPreference, error) {
+	var preferences models.NotificationPreference
+	if token == "" {
+		return preferences, gorm.ErrRecordNotFound
+	}
+	err := db.Where("unsubscribe_token = ?", token).First(&preferences).Error
+	return preferences, err
+}
+
+// Unsubscribe turns off every email for the owner of token. An unknown token
+// returns gorm.ErrRecordNotFound.
+func Unsubscribe(db *gorm.DB, token string) error {
+	preferences, err := ByToken(db, token)
+	if err != nil {
+		return err
+	}
+	return db.Model(&preferences).Updates(map[string]any{
+		"email_status_changes": false,
+		"email_replies":        false,
+		"digest":               models.DigestOff,
+	}).Error
+}
+
+func newToken() string {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,285 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Operation documents one route. Path uses Fiber syntax (e.g. /suggestions/:id<int>);
+// path parameters are derived from it.
+type Operation struct {
+	Method      string
+	Path        string
+	Summary     string
+	Description string
+	Tags        []string
+	Deprecated  bool
+
+	// Query lists query string parameters; set Param.In to document headers.
+	Query []Param
+
+	// Body is a zero value of the JSON request body type, if any.
+	Body any
+
+	// Status is the success status code (200 when zero) and Data a zero value of the
+	// "data" payload. List wraps Data in an array alongside a total "count".
+	Status  int
+	Data    any
+	List    bool
+	Message string
+
+	// Extra documents additional top-level envelope fields, by name, as zero values.
+	Extra map[string]any
+
+	// Produces replaces the JSON envelope with a raw body of this media type (e.g. text/event-stream).
+	Produces string
+
+	// Media replaces the JSON envelope with raw bodies in several media types, each
+	// described by an example value (nil for plain text), e.g. for downloads.
+	Media map[string]any
+
+	// Errors lists the error statuses the operation can return.
+	Errors []int
+}
+
+type Param struct {
+	In          string // "query" when empty, or "header"
+	Name        string
+	Description string
+	Type        string
+	Required    bool
+	Enum        []any
+	Default     any
+}
+
+// Builder assembles a Document from operations.
+type Builder struct {
+	doc *Document
+	ops map[string]Operation
+}
+
+func NewBuilder(info Info) *Builder {
+	b := &Builder{
+		doc: &Document{
+			OpenAPI: "3.1.0",
+			Info:    info,
+			Paths:   map[string]*PathItem{},
+			Components: Components{
+				Schemas: map[string]*Schema{},
+			},
+		},
+		ops: map[string]Operation{},
+	}
+	b.doc.Components.Schemas["Error"] = errorSchema()
+	return b
+}
+
+// Add documents op. Adding the same method and path twice panics, as it is a programming error.
+func (b *Builder) Add(op Operation) *Builder {
+	key := routeKey(op.Method, op.Path)
+	if _, exists := b.ops[key]; exists {
+		panic(fmt.Sprintf("openapi: duplicate operation %s", key))
+	}
+	b.ops[key] = op
+
+	path, params := convertPath(op.Path)
+	item, ok := b.doc.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		b.doc.Paths[path] = item
+	}
+
+	operation := &OperationObject{
+		OperationID: operationID(op.Method, path),
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        op.Tags,
+		Deprecated:  op.Deprecated,
+		Parameters:  params,
+		Responses:   map[string]*Response{},
+	}
+
+	for _, q := range op.Query {
+		schema := &Schema{Type: q.Type, Enum: q.Enum, Default: q.Default}
+		if schema.Type == "" {
+			schema.Type = "string"
+		}
+		in := q.In
+		if in == "" {
+			in = "query"
+		}
+		operation.Parameters = append(operation.Parameters, ParameterObject{
+			Name:        q.Name,
+			In:          in,
+			Description: q.Description,
+			Required:    q.Required,
+			Schema:      schema,
+		})
+	}
+
+	if op.Body != nil {
+		operation.RequestBody = &RequestBodyObject{
+			Required: true,
+			Content: map[string]MediaType{
+				fiber.MIMEApplicationJSON: {Schema: b.schemaOf(reflect.TypeOf(op.Body))},
+			},
+		}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = fiber.StatusOK
+	}
+	success := map[string]MediaType{
+		fiber.MIMEApplicationJSON: {Schema: b.envelope(op)},
+	}
+	if op.Produces != "" {
+		success = map[string]MediaType{
+			op.Produces: {Schema: &Schema{Type: "string"}},
+		}
+	}
+	if len(op.Media) > 0 {
+		success = map[string]MediaType{}
+		for mediaType, value := range op.Media {
+			schema := &Schema{Type: "string"}
+			if value != nil {
+				schema = b.schemaOf(reflect.TypeOf(value))
+			}
+			success[mediaType] = MediaType{Schema: schema}
+		}
+	}
+	// a protocol switch (WebSocket) has no body
+	if status == fiber.StatusSwitchingProtocols {
+		success = nil
+	}
+	operation.Responses[strconv.Itoa(status)] = &Response{
+		Description: http.StatusText(status),
+		Content:     success,
+	}
+	for _, code := range op.Errors {
+		operation.Responses[strconv.Itoa(code)] = &Response{
+			Description: http.StatusText(code),
+			Content: map[string]MediaType{
+				fiber.MIMEApplicationJSON: {Schema: &Schema{Ref: "#/components/schemas/Error"}},
+			},
+		}
+	}
+
+	(*item)[strings.ToLower(op.Method)] = operation
+	return b
+}
+
+// Document returns the assembled document.
+func (b *Builder) Document() *Document {
+	return b.doc
+}
+
+// Verify returns an error naming every route that has no documented operation.
+// HEAD routes generated by Fiber for GET handlers are ignored, as are paths in skip.
+func (b *Builder) Verify(routes []fiber.Route, skip ...string) error {
+	skipped := map[string]bool{}
+	for _, path := range skip {
+		skipped[path] = true
+	}
+
+	missing := []string{}
+	for _, route := range routes {
+		if route.Method == fiber.MethodHead || skipped[route.Path] {
+			continue
+		}
+		key := routeKey(route.Method, route.Path)
+		if _, ok := b.ops[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("routes missing from the OpenAPI document: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func (b *Builder) envelope(op Operation) *Schema {
+	schema := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"success": {Type: "boolean"},
+		},
+		Required: []string{"success"},
+	}
+
+	if op.Message != "" {
+		schema.Properties["message"] = &Schema{Type: "string", Enum: []any{op.Message}}
+	}
+
+	if op.Data != nil {
+		data := b.schemaOf(reflect.TypeOf(op.Data))
+		if op.List {
+			data = &Schema{Type: "array", Items: data}
+			schema.Properties["count"] = &Schema{Type: "integer", Description: "Total number of matching records"}
+		}
+		schema.Properties["data"] = data
+	}
+
+	for name, value := range op.Extra {
+		schema.Properties[name] = b.schemaOf(reflect.TypeOf(value))
+	}
+
+	return schema
+}
+
+func errorSchema() *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"success": {Type: "boolean", Enum: []any{false}},
+			"error":   {Type: "string", Description: "Human readable message"},
+			"code":    {Type: "string", Description: "Machine readable error code"},
+			"details": {Description: "Additional information about the error"},
+		},
+		Required: []string{"success", "error", "code"},
+	}
+}
+
+func routeKey(method, path string) string {
+	return strings.ToUpper(method) + " " + path
+}
+
+var fiberParam = regexp.MustCompile(`:([A-Za-z0-9_]+)(<[^>]*>)?\??`)
+
+// convertPath turns /suggestions/:id<int> into /suggestions/{id} and its parameter list.
+func convertPath(path string) (string, []ParameterObject) {
+	params := []ParameterObject{}
+	converted := fiberParam.ReplaceAllStringFunc(path, func(match string) string {
+		parts := fiberParam.FindStringSubmatch(match)
+		schema := &Schema{Type: "string"}
+		if strings.Contains(parts[2], "int") {
+			schema = &Schema{Type: "integer"}
+		}
+		params = append(params, ParameterObject{Name: parts[1], In: "path", Required: true, Schema: schema})
+		return "{" + parts[1] + "}"
+	})
+	return converted, params
+}
+
+var nonWord = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+func operationID(method, path string) string {
+	parts := nonWord.Split(strings.ReplaceAll(strings.ReplaceAll(path, "{", "by_"), "}", ""), -1)
+	id := strings.ToLower(method)
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		id += strings.ToUpper(part[:1]) + part[1:]
+	}
+	return id
+}
@@ -0,0 +1,43 @@
+package openapi
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler serves the document as JSON.
+func Handler(doc *Document) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return c.Status(fiber.StatusOK).JSON(doc)
+	}
+}
+
+// UIHandler serves a Swagger UI page that loads the document from specURL.
+func UIHandler(title, specURL string) fiber.Handler {
+	page := fmt.Sprintf(uiTemplate, title, specURL)
+	return func(c *fiber.Ctx) error {
+		c.Type("html", "utf-8")
+		return c.Status(fiber.StatusOK).SendString(page)
+	}
+}
+
+const uiTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8" />
+  <meta name="viewport" content="width=device-width, initial-scale=1" />
+  <title>%s</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css" />
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = function () {
+      window.ui = SwaggerUIBundle({ url: %q, dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>
+`
@@ -0,0 +1,42 @@
+package openapi
+
+import (
+	"reflect"
+	"testing"
+)
+
+type child struct {
+	Name string `json:"name"`
+}
+
+type parent struct {
+	Child    child    `json:"child"`
+	Optional *child   `json:"optional"`
+	Note     *string  `json:"note"`
+	Children []*child `json:"children"`
+}
+
+func TestSchemaOfNullability(t *testing.T) {
+	b := NewBuilder(Info{Title: "test", Version: "1"})
+	b.schemaOf(reflect.TypeOf(parent{}))
+	schema := b.doc.Components.Schemas["parent"]
+
+	ref := &Schema{Ref: "#/components/schemas/child"}
+	nullableRef := &Schema{AnyOf: []*Schema{ref, {Type: "null"}}}
+	tests := []struct {
+		field string
+		want  *Schema
+	}{
+		{"child", ref},
+		{"optional", nullableRef},
+		{"note", &Schema{Type: []string{"string", "null"}}},
+		{"children", &Schema{Type: "array", Items: nullableRef}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			if got := schema.Properties[tt.field]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("schema = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
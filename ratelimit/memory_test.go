@@ -0,0 +1,105 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	policy := Policy{Name: "test", Limit: 2, Window: time.Minute}
+
+	type step struct {
+		after         time.Duration // since the previous step
+		wantAllowed   bool
+		wantRemaining int
+		wantRetry     time.Duration
+	}
+	tests := []struct {
+		name   string
+		policy Policy
+		steps  []step
+	}{
+		{
+			name:   "spends the bucket then refuses",
+			policy: policy,
+			steps: []step{
+				{0, true, 1, 0},
+				{0, true, 0, 0},
+				{0, false, 0, 30 * time.Second},
+			},
+		},
+		{
+			name:   "refills evenly over the window",
+			policy: policy,
+			steps: []step{
+				{0, true, 1, 0},
+				{0, true, 0, 0},
+				{30 * time.Second, true, 0, 0},
+				{10 * time.Second, false, 0, 20 * time.Second},
+			},
+		},
+		{
+			name:   "never holds more than the capacity",
+			policy: policy,
+			steps: []step{
+				{0, true, 1, 0},
+				{time.Hour, true, 1, 0},
+			},
+		},
+		{
+			name:   "burst caps the capacity",
+			policy: Policy{Name: "burst", Limit: 60, Window: time.Minute, Burst: 1},
+			steps: []step{
+				{0, true, 0, 0},
+				{0, false, 0, time.Second},
+				{time.Second, true, 0, 0},
+			},
+		},
+		{
+			name:   "zero limit is disabled",
+			policy: Policy{Name: "off", Limit: 0, Window: time.Minute},
+			steps: []step{
+				{0, true, 0, 0},
+				{0, true, 0, 0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			store := NewMemoryStore()
+			store.now = func() time.Time { return now }
+
+			for i, step := range tt.steps {
+				now = now.Add(step.after)
+				result, err := store.Take(context.Background(), "key", tt.policy)
+				if err != nil {
+					t.Fatalf("step %d: %v", i, err)
+				}
+				if result.Allowed != step.wantAllowed || result.Remaining != step.wantRemaining {
+					t.Errorf("step %d: allowed %v remaining %d, want %v and %d",
+						i, result.Allowed, result.Remaining, step.wantAllowed, step.wantRemaining)
+				}
+				if result.RetryAfter.Round(time.Millisecond) != step.wantRetry {
+					t.Errorf("step %d: retry after %s, want %s", i, result.RetryAfter, step.wantRetry)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryStoreKeysAreIndependent(t *testing.T) {
+	store := NewMemoryStore()
+	policy := Policy{Name: "test", Limit: 1, Window: time.Minute}
+
+	for _, key := range []string{"a", "b"} {
+		if result, _ := store.Take(context.Background(), key, policy); !result.Allowed {
+			t.Errorf("first take of %q refused", key)
+		}
+	}
+	if result, _ := store.Take(context.Background(), "a", policy); result.Allowed {
+		t.Error("second take of \"a\" allowed")
+	}
+}
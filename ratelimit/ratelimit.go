@@ -0,0 +1,124 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"feedback-io.backend/apierror"
+	"feedback-io.backend/auth"
+	"github.com/gofiber/fiber/v2"
+)
+
+// KeyFunc identifies the client a policy applies to. Returning "" skips the policy.
+type KeyFunc func(c *fiber.Ctx) string
+
+// Policy is a token bucket: Limit tokens are refilled evenly over Window and at most
+// Burst (Limit when zero) can be spent at once. A Limit or Window of zero disables it.
+type Policy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+	Burst  int
+	Key    KeyFunc
+}
+
+// Disabled reports whether the policy lets every request through.
+func (p Policy) Disabled() bool {
+	return p.Limit <= 0 || p.Window <= 0
+}
+
+func (p Policy) capacity() int {
+	if p.Burst > 0 {
+		return p.Burst
+	}
+	return p.Limit
+}
+
+// Result is the outcome of taking a token.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until the next token, when not allowed
+}
+
+// Store keeps buckets. MemoryStore is used by default; a shared implementation
+// (e.g. Redis) lets several replicas enforce the same limits.
+type Store interface {
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+}
+
+// ByIP keys requests by client address.
+func ByIP(c *fiber.Ctx) string {
+	return "ip:" + c.IP()
+}
+
+// ByUser keys requests by authenticated user and skips anonymous ones.
+func ByUser(c *fiber.Ctx) string {
+	if id, ok := auth.UserID(c); ok {
+		return "user:" + strconv.FormatUint(uint64(id), 10)
+	}
+	return ""
+}
+
+// Limit enforces every policy on the route and sets the RateLimit-* headers from the
+// most restrictive one. Exhausted requests get a 429 in the standard error envelope.
+func Limit(store Store, policies ...Policy) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var tightest *Result
+		var tightestPolicy Policy
+
+		for _, policy := range policies {
+			if policy.Disabled() {
+				continue
+			}
+			key := policy.Key(c)
+			if key == "" {
+				continue
+			}
+
+			result, err := store.Take(c.UserContext(), policy.Name+":"+key, policy)
+			if err != nil {
+				// fail open: an unavailable store must not take the API down
+				continue
+			}
+
+			if tightest == nil || !result.Allowed || (tightest.Allowed && result.Remaining < tightest.Remaining) {
+				res := result
+				tightest = &res
+				tightestPolicy = policy
+			}
+			if !result.Allowed {
+				break
+			}
+		}
+
+		if tightest == nil {
+			return c.Next()
+		}
+
+		c.Set("RateLimit-Limit", strconv.Itoa(tightest.Limit))
+		c.Set("RateLimit-Remaining", strconv.Itoa(tightest.Remaining))
+		c.Set("RateLimit-Reset", strconv.Itoa(seconds(tightest.Reset)))
+		c.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", tightestPolicy.Limit, seconds(tightestPolicy.Window)))
+
+		if !tightest.Allowed {
+			retryAfter := seconds(tightest.RetryAfter)
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+			return apierror.TooManyRequests("Rate limit exceeded, retry later").WithDetails(fiber.Map{
+				"policy":      tightestPolicy.Name,
+				"retry_after": retryAfter,
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
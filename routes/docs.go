@@ -0,0 +1,558 @@
+package routes
+
+import (
+	"slices"
+
+	"feedback-io.backend/analytics"
+	"feedback-io.backend/audit"
+	controllers "feedback-io.backend/controllers"
+	"feedback-io.backend/idempotency"
+	"feedback-io.backend/importer"
+	"feedback-io.backend/middleware"
+	"feedback-io.backend/models"
+	"feedback-io.backend/moderation"
+	"feedback-io.backend/openapi"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Spec documents every route registered by Setups. TestSpecCoversRoutes fails when a route
+// is added to Setups without a matching operation here.
+func Spec() *openapi.Builder {
+	b := openapi.NewBuilder(openapi.Info{
+		Title:       "Feedback IO API",
+		Version:     "1.0.0",
+		Description: "Backend for https://feedback-io.netlify.app",
+	})
+
+	for _, op := range v1Operations() {
+		op.Path = V1Prefix + op.Path
+		b.Add(op)
+	}
+
+	// the unversioned aliases only cover the suggestion routes
+	for _, op := range suggestionOperations() {
+		op.Deprecated = true
+		op.Description = "Deprecated alias of " + V1Prefix + op.Path + "."
+		b.Add(op)
+	}
+
+	return b
+}
+
+var idempotencyKeyParam = openapi.Param{
+	In:          "header",
+	Name:        idempotency.HeaderKey,
+	Description: "Replays the first response when the request is retried with the same key",
+}
+
+// v1Operations documents the routes registered by V1, relative to V1Prefix.
+func v1Operations() []openapi.Operation {
+	return slices.Concat(suggestionOperations(), []openapi.Operation{{
+		Method:      fiber.MethodPatch,
+		Path:        "/suggestions/:id<int>/status",
+		Summary:     "Change the status of a suggestion",
+		Description: "Requires the admin or moderator role.",
+		Tags:        []string{"suggestions"},
+		Body:        controllers.UpdateStatusInput{},
+		Data:        models.Suggestion{},
+		Errors:      []int{fiber.StatusBadRequest, fiber.StatusUnauthorized, fiber.StatusForbidden, fiber.StatusNotFound, fiber.StatusUnprocessableEntity, fiber.StatusInternalServerError},
+	}, {
+		Method:      fiber.MethodPost,
+		Path:        "/suggestions/:id<int>/comments",
+		Summary:     "Comment on a suggestion",
+		Description: heldDescription,
+		Tags:        []string{"comments"},
+		Body:        controllers.CreateCommentInput{},
+		Status:      fiber.StatusCreated,
+		Data:        models.Comment{},
+		Errors:      []int{fiber.StatusBadRequest, fiber.StatusUnauthorized, fiber.StatusNotFound, fiber.StatusUnprocessableEntity, fiber.StatusInternalServerError},
+	}, {
+		Method:      fiber.MethodPost,
+		Path:        "/suggestions/:id<int>/comments/:comment<int>/replies",
+		Summary:     "Reply to a comment",
+		Description: "The comment author is notified and, unless they opted out, emailed. " + heldDescription,
+		Tags:        []string{"comments"},
+		Body:        controllers.CreateReplyInput{},
+		Status:      fiber.StatusCreated,
+		Data:        models.Reply{},
+		Errors:      []int{fiber.StatusBadRequest, fiber.StatusUnauthorized, fiber.StatusNotFound, fiber.StatusUnprocessableEntity, fiber.StatusInternalServerError},
+	}, {
+		Method:  fiber.MethodGet,
+		Path:    "/events",
+		Summary: "Stream suggestion and comment events (Server-Sent Events)",
+		Description: "Emits suggestion.created, suggestion.voted, suggestion.status_changed and comment.created. " +
+			"Reconnecting clients resume after the Last-Event-ID header, or get a `reset` event when the events they missed are no longer kept.",
+		Tags: []string{"events"},
+		Query: []openapi.Param{
+			{Name: "suggestion_id", Type: "integer", Description: "Only events about this suggestion"},
+			{Name: "category", Type: "integer", Description: "Only events about suggestions in this category"},
+			{Name: "last_event_id", Type: "integer", Description: "Alternative to the Last-Event-ID header"},
+			{In: "header", Name: "Last-Event-ID", Description: "Resume after this event id"},
+		},
+		Produces: "text/event-stream",
+		Errors:   []int{fiber.StatusBadRequest},
+	}, {
+		Method:      fiber.MethodGet,
+		Path:        "/ws/ticket",
+		Summary:     "Issue a ticket for the WebSocket auth handshake",
+		Description: "The ticket is valid for one minute and is sent as the first socket message.",
+		Tags:        []string{"events"},
+		Data:        controllers.SocketTicketResponse{},
+		Errors:      []int{fiber.StatusUnauthorized},
+	}, {
+		Method:  fiber.MethodGet,
+		Path:    "/ws/suggestions/:id<int>",
+		Summary: "Live vote count and comments of a suggestion (WebSocket)",
+		Description: "Send {\"type\":\"auth\",\"ticket\":\"...\"} within 10 seconds of connecting, then " +
+			"{\"type\":\"vote\",\"vote\":\"up|down\"} to vote. The server sends ready, votes, comment, voted " +
+			"and error messages, and pings every 30 seconds.",
+		Tags:   []string{"events"},
+		Status: fiber.StatusSwitchingProtocols,
+		Errors: []int{fiber.StatusUpgradeRequired},
+	}}, notificationOperations(), webhookOperations(), jobOperations(), taskOperations(), analyticsOperations(), importOperations(), auditOperations(), moderationOperations())
+}
+
+// notificationOperations documents following suggestions and the caller's inbox.
+func notificationOperations() []openapi.Operation {
+	common := []int{fiber.StatusUnauthorized, fiber.StatusInternalServerError}
+
+	return []openapi.Operation{{
+		Method:  fiber.MethodPut,
+		Path:    "/suggestions/:id<int>/subscription",
+		Summary: "Follow a suggestion",
+		Tags:    []string{"notifications"},
+		Data:    models.Subscription{},
+		Errors:  append([]int{fiber.StatusBadRequest, fiber.StatusNotFound}, common...),
+	}, {
+		Method:  fiber.MethodDelete,
+		Path:    "/suggestions/:id<int>/subscription",
+		Summary: "Unfollow a suggestion",
+		Tags:    []string{"notifications"},
+		Message: "Suggestion unfollowed successfully",
+		Errors:  append([]int{fiber.StatusBadRequest}, common...),
+	}, {
+		Method:  fiber.MethodGet,
+		Path:    "/me/notifications",
+		Summary: "List the caller's notifications, newest first",
+		Tags:    []string{"notifications"},
+		Query: []openapi.Param{
+			{Name: "offset", Type: "integer", Default: 0},
+			{Name: "limit", Type: "integer", Default: 20},
+			{Name: "unread", Type: "boolean", Description: "Only unread notifications"},
+		},
+		Data:   models.Notification{},
+		List:   true,
+		Extra:  map[string]any{"unread": int64(0)},
+		Errors: append([]int{fiber.StatusBadRequest}, common...),
+	}, {
+		Method:  fiber.MethodPost,
+		Path:    "/me/notifications/read",
+		Summary: "Mark all notifications as read",
+		Tags:    []string{"notifications"},
+		Data:    controllers.MarkReadResponse{},
+		Errors:  common,
+	}, {
+		Method:  fiber.MethodPost,
+		Path:    "/me/notifications/:id<int>/read",
+		Summary: "Mark a notification as read",
+		Tags:    []string{"notifications"},
+		Data:    models.Notification{},
+		Errors:  append([]int{fiber.StatusBadRequest, fiber.StatusNotFound}, common...),
+	}, {
+		Method:  fiber.MethodGet,
+		Path:    "/me/preferences",
+		Summary: "Get the caller's email preferences",
+		Tags:    []string{"notifications"},
+		Data:    models.NotificationPreference{},
+		Errors:  common,
+	}, {
+		Method:  fiber.MethodPatch,
+		Path:    "/me/preferences",
+		Summary: "Update the caller's email preferences",
+		Tags:    []string{"notifications"},
+		Body:    controllers.UpdatePreferencesInput{},
+		Data:    models.NotificationPreference{},
+		Errors:  append([]int{fiber.StatusBadRequest, fiber.StatusUnprocessableEntity}, common...),
+	}, {
+		Method:      fiber.MethodGet,
+		Path:        "/unsubscribe",
+		Summary:     "Check an unsubscribe token (the link in every email)",
+		Description: "Changes nothing. Browsers get an HTML page with a form that POSTs the unsubscribe.",
+		Tags:        []string{"notifications"},
+		Query:       unsubscribeQuery,
+		Data:        controllers.UnsubscribeState{},
+		Errors:      []int{fiber.StatusNotFound, fiber.StatusInternalServerError},
+	}, {
+		Method:      fiber.MethodPost,
+		Path:        "/unsubscribe",
+		Summary:     "Turn off every email",
+		Description: "Also the one-click unsubscribe of mail clients (RFC 8058).",
+		Tags:        []string{"notifications"},
+		Query:       unsubscribeQuery,
+		Message:     "Unsubscribed from all emails",
+		Errors:      []int{fiber.StatusNotFound, fiber.StatusInternalServerError},
+	}}
+}
+
+var unsubscribeQuery = []openapi.Param{
+	{Name: "token", Required: true, Description: "Unsubscribe token from the email"},
+}
+
+var adminErrors = []int{fiber.StatusUnauthorized, fiber.StatusForbidden}
+
+// heldDescription documents what happens to content the content checks flag.
+const heldDescription = "Content flagged by the spam and profanity checks is still created (201), but hidden " +
+	"(`hidden_at` set) and queued for moderation; the response then carries a `message`."
+
+// webhookOperations documents the admin webhook routes, which require the admin role.
+func webhookOperations() []openapi.Operation {
+	withAdmin := func(codes ...int) []int {
+		return append(append([]int{}, adminErrors...), codes...)
+	}
+
+	return []openapi.Operation{{
+		Method:  fiber.MethodGet,
+		Path:    "/admin/webhooks",
+		Summary: "List webhooks",
+		Tags:    []string{"webhooks"},
+		Data:    models.Webhook{},
+		List:    true,
+		Errors:  withAdmin(fiber.StatusInternalServerError),
+	}, {
+		Method:      fiber.MethodPost,
+		Path:        "/admin/webhooks",
+		Summary:     "Create a webhook",
+		Description: "The signing secret is generated unless given, and only returned in this response.",
+		Tags:        []string{"webhooks"},
+		Body:        controllers.CreateWebhookInput{},
+		Status:      fiber.StatusCreated,
+		Data:        controllers.WebhookWithSecret{},
+		Errors:      withAdmin(fiber.StatusBadRequest, fiber.StatusUnprocessableEntity, fiber.StatusInternalServerError),
+	}, {
+		Method:  fiber.MethodGet,
+		Path:    "/admin/webhooks/:id<int>",
+		Summary: "Get a webhook",
+		Tags:    []string{"webhooks"},
+		Data:    models.Webhook{},
+		Errors:  withAdmin(fiber.StatusBadRequest, fiber.StatusNotFound, fiber.StatusInternalServerError),
+	}, {
+		Method:      fiber.MethodPatch,
+		Path:        "/admin/webhooks/:id<int>",
+		Summary:     "Update a webhook",
+		Description: "With rotate_secret the response includes the new secret.",
+		Tags:        []string{"webhooks"},
+		Body:        controllers.UpdateWebhookInput{},
+		Data:        controllers.WebhookWithSecret{},
+		Errors:      withAdmin(fiber.StatusBadRequest, fiber.StatusNotFound, fiber.StatusUnprocessableEntity, fiber.StatusInternalServerError),
+	}, {
+		Method:  fiber.MethodDelete,
+		Path:    "/admin/webhooks/:id<int>",
+		Summary: "Delete a webhook and its delivery log",
+		Tags:    []string{"webhooks"},
+		Message: "Webhook deleted successfully",
+		Errors:  withAdmin(fiber.StatusBadRequest, fiber.StatusNotFound, fiber.StatusInternalServerError),
+	}, {
+		Method:  fiber.MethodGet,
+		Path:    "/admin/webhooks/:id<int>/deliveries",
+		Summary: "List the deliveries of a webhook, newest first",
+		Tags:    []string{"webhooks"},
+		Query: []openapi.Param{
+			{Name: "offset", Type: "integer", Default: 0},
+			{Name: "limit", Type: "integer", Default: 20},
+			{Name: "status", Enum: []any{models.DeliveryPending, models.DeliverySucceeded, models.DeliveryFailed}},
+		},
+		Data:   models.WebhookDelivery{},
+		List:   true,
+		Errors: withAdmin(fiber.StatusBadRequest, fiber.StatusNotFound, fiber.StatusInternalServerError),
+	}, {
+		Method:  fiber.MethodPost,
+		Path:    "/admin/webhooks/:id<int>/deliveries/:delivery<int>/redeliver",
+		Summary: "Queue a delivery again",
+		Tags:    []string{"webhooks"},
+		Status:  fiber.StatusAccepted,
+		Data:    models.WebhookDelivery{},
+		Errors:  withAdmin(fiber.StatusBadRequest, fiber.StatusNotFound, fiber.StatusInternalServerError),
+	}}
+}
+
+// jobOperations documents the admin view of the background job queue.
+func jobOperations() []openapi.Operation {
+	withAdmin := func(codes ...int) []int {
+		return append(append([]int{}, adminErrors...), codes...)
+	}
+
+	return []openapi.Operation{{
+		Method:  fiber.MethodGet,
+		Path:    "/admin/jobs",
+		Summary: "List background jobs, newest first",
+		Tags:    []string{"jobs"},
+		Query: []openapi.Param{
+			{Name: "offset", Type: "integer", Default: 0},
+			{Name: "limit", Type: "integer", Default: 20},
+			{Name: "status", Enum: []any{models.JobPending, models.JobRunning, models.JobSucceeded, models.JobFailed}},
+			{Name: "kind", Description: "Only return jobs of this kind, e.g. email.send"},
+		},
+		Data:   models.Job{},
+		List:   true,
+		Errors: withAdmin(fiber.StatusBadRequest, fiber.StatusUnprocessableEntity, fiber.StatusInternalServerError),
+	}, {
+		Method:  fiber.MethodGet,
+		Path:    "/admin/jobs/stats",
+		Summary: "Count background jobs per status",
+		Tags:    []string{"jobs"},
+		Data:    controllers.JobStatsResponse{},
+		Errors:  withAdmin(fiber.StatusInternalServerError),
+	}, {
+		Method:  fiber.MethodGet,
+		Path:    "/admin/jobs/:id<int>",
+		Summary: "Get a background job",
+		Tags:    []string{"jobs"},
+		Data:    models.Job{},
+		Errors:  withAdmin(fiber.StatusBadRequest, fiber.StatusNotFound, fiber.StatusInternalServerError),
+	}, {
+		Method:      fiber.MethodPost,
+		Path:        "/admin/jobs/:id<int>/retry",
+		Summary:     "Retry a failed job",
+		Description: "The job runs again as soon as a worker is free, with a fresh set of attempts.",
+		Tags:        []string{"jobs"},
+		Status:      fiber.StatusAccepted,
+		Data:        models.Job{},
+		Errors:      withAdmin(fiber.StatusBadRequest, fiber.StatusNotFound, fiber.StatusConflict, fiber.StatusInternalServerError),
+	}}
+}
+
+// analyticsOperations documents the aggregated activity for product managers.
+func analyticsOperations() []openapi.Operation {
+	filters := []openapi.Param{
+		{Name: "from", Description: "First day included (YYYY-MM-DD); 30 days before to by default"},
+		{Name: "to", Description: "Last day included (YYYY-MM-DD); today by default"},
+		{Name: "category", Type: "integer", Description: "Only count activity on suggestions in this category"},
+	}
+	codes := []int{fiber.StatusBadRequest, fiber.StatusUnauthorized, fiber.StatusForbidden,
+		fiber.StatusUnprocessableEntity, fiber.StatusInternalServerError}
+
+	return []openapi.Operation{{
+		Method:      fiber.MethodGet,
+		Path:        "/analytics/summary",
+		Summary:     "Count suggestions, votes and comments in a date range",
+		Description: "Totals and breakdowns by category and by current status. Requires the admin or analyst role.",
+		Tags:        []string{"analytics"},
+		Query:       filters,
+		Data:        analytics.Summary{},
+		Errors:      codes,
+	}, {
+		Method:      fiber.MethodGet,
+		Path:        "/analytics/timeseries",
+		Summary:     "Count suggestions, votes and comments per day or week",
+		Description: "Weeks start on Monday. Without group_by every period is listed, with zeros when nothing happened. Requires the admin or analyst role.",
+		Tags:        []string{"analytics"},
+		Query: append(slices.Clone(filters),
+			openapi.Param{Name: "interval", Enum: []any{analytics.Day, analytics.Week}, Default: analytics.Day},
+			openapi.Param{Name: "group_by", Enum: []any{analytics.ByCategory, analytics.ByStatus}},
+		),
+		Data:   analytics.Series{},
+		Errors: codes,
+	}, {
+		Method:  fiber.MethodGet,
+		Path:    "/export/suggestions",
+		Summary: "Download suggestions as CSV, JSON or NDJSON",
+		Description: "Streams every suggestion, ordered by id. The CSV has one row per suggestion, or with " +
+			"include_comments one row per suggestion, comment and reply told apart by the type column. " +
+			"Requires the admin or analyst role.",
+		Tags: []string{"analytics"},
+		Query: []openapi.Param{
+			{Name: "format", Enum: []any{controllers.ExportCSV, controllers.ExportJSON, controllers.ExportNDJSON}, Default: controllers.ExportCSV},
+			{Name: "category", Type: "integer", Description: "Only export suggestions in this category"},
+			{Name: "include_comments", Type: "boolean", Default: false, Description: "Add comments and their replies"},
+		},
+		Media: map[string]any{
+			"text/csv":                nil,
+			fiber.MIMEApplicationJSON: []controllers.ExportedSuggestion{},
+			"application/x-ndjson":    controllers.ExportedSuggestion{},
+		},
+		Errors: codes,
+	}}
+}
+
+// importOperations documents the bulk import of suggestions from another board.
+func importOperations() []openapi.Operation {
+	return []openapi.Operation{{
+		Method:  fiber.MethodPost,
+		Path:    "/import",
+		Summary: "Import suggestions and comments from CSV or JSON",
+		Description: "The body is a JSON array of records, or CSV with a header naming the same fields; " +
+			"the CSV comments cell holds one \"author@example.com: text\" comment per line. Categories are " +
+			"matched by name and users by email, and created when missing. Everything is imported in one " +
+			"transaction: when a row is invalid nothing is, and the 422 details are the report with every " +
+			"row error. dry_run checks and rolls back. Requires the admin role.",
+		Tags: []string{"suggestions"},
+		Query: []openapi.Param{
+			{Name: "format", Enum: []any{importer.CSV, importer.JSON}, Description: "Input format; derived from the Content-Type by default"},
+			{Name: "dry_run", Type: "boolean", Default: false, Description: "Roll back instead of committing"},
+		},
+		Body:   []importer.Record{},
+		Status: fiber.StatusCreated,
+		Data:   importer.Report{},
+		Errors: append(append([]int{}, adminErrors...), fiber.StatusBadRequest, fiber.StatusUnprocessableEntity, fiber.StatusInternalServerError),
+	}}
+}
+
+// auditOperations documents the audit log of administrative and destructive actions.
+func auditOperations() []openapi.Operation {
+	return []openapi.Operation{{
+		Method:      fiber.MethodGet,
+		Path:        "/admin/audit",
+		Summary:     "List audit events, newest first",
+		Description: "Who changed or deleted what, with the state of the target before and after. Requires the admin role.",
+		Tags:        []string{"audit"},
+		Query: []openapi.Param{
+			{Name: "offset", Type: "integer", Default: 0},
+			{Name: "limit", Type: "integer", Default: 20},
+			{Name: "actor_id", Type: "integer", Description: "Only actions of this user; 0 for the command line"},
+			{Name: "action", Description: "e.g. suggestion.delete"},
+			{Name: "target_type", Enum: []any{audit.TargetSuggestion, audit.TargetWebhook, audit.TargetJob, audit.TargetTask, audit.TargetImport}},
+			{Name: "target_id", Description: "Only actions on this target, with target_type"},
+			{Name: "from", Description: "First day included (YYYY-MM-DD)"},
+			{Name: "to", Description: "Last day included (YYYY-MM-DD)"},
+		},
+		Data:   models.AuditEvent{},
+		List:   true,
+		Errors: append(append([]int{}, adminErrors...), fiber.StatusBadRequest, fiber.StatusInternalServerError),
+	}}
+}
+
+// moderationOperations documents reporting content and the moderators' queue.
+func moderationOperations() []openapi.Operation {
+	targets := []any{models.ReportSuggestion, models.ReportComment, models.ReportReply}
+	moderatorErrors := []int{fiber.StatusUnauthorized, fiber.StatusForbidden}
+
+	return []openapi.Operation{{
+		Method:  fiber.MethodPost,
+		Path:    "/reports",
+		Summary: "Report a suggestion, comment or reply",
+		Description: "When MODERATION_AUTO_HIDE_REPORTS is set, content with that many open reports from accounts " +
+			"older than MODERATION_REPORTER_MIN_AGE is hidden from public queries until a moderator reviews it. " +
+			"A user reports a piece of content once.",
+		Tags:   []string{"moderation"},
+		Body:   controllers.CreateReportInput{},
+		Status: fiber.StatusCreated,
+		Data:   models.Report{},
+		Errors: []int{fiber.StatusBadRequest, fiber.StatusUnauthorized, fiber.StatusNotFound, fiber.StatusConflict,
+			fiber.StatusUnprocessableEntity, fiber.StatusTooManyRequests, fiber.StatusInternalServerError},
+	}, {
+		Method:      fiber.MethodGet,
+		Path:        "/moderation/queue",
+		Summary:     "List reported content, longest waiting first",
+		Description: "Each item is a suggestion, comment or reply with its open reports. Requires the moderator or admin role.",
+		Tags:        []string{"moderation"},
+		Query: []openapi.Param{
+			{Name: "offset", Type: "integer", Default: 0},
+			{Name: "limit", Type: "integer", Default: 20},
+			{Name: "target_type", Enum: targets},
+		},
+		Data:   moderation.Item{},
+		List:   true,
+		Errors: append(slices.Clone(moderatorErrors), fiber.StatusBadRequest, fiber.StatusUnprocessableEntity, fiber.StatusInternalServerError),
+	}, {
+		Method:  fiber.MethodPost,
+		Path:    "/moderation/:type/:id<int>",
+		Summary: "Hide, unhide, delete or dismiss reported content",
+		Description: "type is suggestion, comment or reply. Every action resolves the open reports of the content " +
+			"and is recorded in the audit log; delete also removes the comments and replies below it. " +
+			"Requires the moderator or admin role.",
+		Tags:   []string{"moderation"},
+		Body:   controllers.ModerationActionInput{},
+		Data:   moderation.Item{},
+		Errors: append(slices.Clone(moderatorErrors), fiber.StatusBadRequest, fiber.StatusNotFound, fiber.StatusUnprocessableEntity, fiber.StatusInternalServerError),
+	}}
+}
+
+// taskOperations documents the admin view of the scheduled maintenance tasks.
+func taskOperations() []openapi.Operation {
+	withAdmin := func(codes ...int) []int {
+		return append(append([]int{}, adminErrors...), codes...)
+	}
+
+	return []openapi.Operation{{
+		Method:      fiber.MethodGet,
+		Path:        "/admin/tasks",
+		Summary:     "List scheduled tasks",
+		Description: "Each task's schedule, status and duration of the last run, and when it runs next.",
+		Tags:        []string{"jobs"},
+		Data:        models.ScheduledTask{},
+		List:        true,
+		Errors:      withAdmin(fiber.StatusInternalServerError),
+	}, {
+		Method:  fiber.MethodPost,
+		Path:    "/admin/tasks/:name/run",
+		Summary: "Run a scheduled task now",
+		Tags:    []string{"jobs"},
+		Status:  fiber.StatusAccepted,
+		Data:    models.ScheduledTask{},
+		Errors:  withAdmin(fiber.StatusNotFound, fiber.StatusConflict, fiber.StatusInternalServerError),
+	}}
+}
+
+func suggestionOperations() []openapi.Operation {
+	return []openapi.Operation{{
+		Method:  fiber.MethodGet,
+		Path:    "/suggestions",
+		Summary: "List suggestions",
+		Tags:    []string{"suggestions"},
+		Query: []openapi.Param{
+			{Name: "offset", Type: "integer", Default: 0, Description: "Number of suggestions to skip"},
+			{Name: "limit", Type: "integer", Default: 10, Description: "Maximum number of suggestions to return"},
+			{Name: "category", Type: "integer", Description: "Only return suggestions in this category"},
+			{Name: "sort", Enum: []any{controllers.SortNewest, controllers.SortTrending}, Default: controllers.SortNewest},
+		},
+		Data:   models.Suggestion{},
+		List:   true,
+		Errors: []int{fiber.StatusBadRequest, fiber.StatusUnprocessableEntity, fiber.StatusInternalServerError},
+	}, {
+		Method:  fiber.MethodGet,
+		Path:    "/suggestions/:id<int>",
+		Summary: "Get a suggestion",
+		Tags:    []string{"suggestions"},
+		Data:    models.Suggestion{},
+		Errors:  []int{fiber.StatusBadRequest, fiber.StatusNotFound, fiber.StatusInternalServerError},
+	}, {
+		Method:  fiber.MethodPut,
+		Path:    "/suggestions/:id<int>/vote",
+		Summary: "Vote a suggestion up or down",
+		Tags:    []string{"suggestions"},
+		Query: []openapi.Param{
+			{Name: "vote", Enum: []any{"up", "down"}, Default: "up"},
+			idempotencyKeyParam,
+		},
+		Data:   models.Suggestion{},
+		Errors: []int{fiber.StatusBadRequest, fiber.StatusNotFound, fiber.StatusConflict, fiber.StatusUnprocessableEntity, fiber.StatusTooManyRequests, fiber.StatusInternalServerError},
+	}, {
+		Method:      fiber.MethodPost,
+		Path:        "/suggestions",
+		Summary:     "Create a suggestion",
+		Description: heldDescription,
+		Tags:        []string{"suggestions"},
+		Query:       []openapi.Param{idempotencyKeyParam},
+		Body:        controllers.CreateSuggestionInput{},
+		Status:      fiber.StatusCreated,
+		Data:        models.Suggestion{},
+		Errors:      []int{fiber.StatusBadRequest, fiber.StatusConflict, fiber.StatusUnprocessableEntity, fiber.StatusTooManyRequests, fiber.StatusInternalServerError},
+	}, {
+		Method:      fiber.MethodDelete,
+		Path:        "/suggestions/:id",
+		Summary:     "Delete a suggestion with its comments and replies",
+		Description: "Requires the admin or moderator role.",
+		Tags:        []string{"suggestions"},
+		Data:        models.Suggestion{},
+		Message:     "Suggestion deleted successfully",
+		Errors:      []int{fiber.StatusBadRequest, fiber.StatusUnauthorized, fiber.StatusForbidden, fiber.StatusNotFound, fiber.StatusInternalServerError},
+	}}
+}
+
+// Docs serves the OpenAPI document and a Swagger UI page, the latter with its own
+// content security policy since it loads Swagger UI from a CDN.
+func Docs(app *fiber.App, uiPolicy string) {
+	doc := Spec().Document()
+	app.Get("/openapi.json", openapi.Handler(doc))
+	app.Get("/docs", middleware.ContentSecurityPolicy(uiPolicy), openapi.UIHandler(doc.Info.Title, "/openapi.json"))
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSpecCoversRoutes(t *testing.T) {
+	app := fiber.New()
+	Setups(app)
+
+	if err := Spec().Verify(app.GetRoutes(true)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSpecBuilds(t *testing.T) {
+	doc := Spec().Document()
+	if len(doc.Paths) == 0 {
+		t.Fatal("document has no paths")
+	}
+}
@@ -1,18 +1,141 @@
 package routes
 
 import (
+	"time"
+
+	"feedback-io.backend/auth"
+	sql "feedback-io.backend/config"
 	controllers "feedback-io.backend/controllers"
+	"feedback-io.backend/idempotency"
+	"feedback-io.backend/metrics"
+	"feedback-io.backend/middleware"
+	"feedback-io.backend/ratelimit"
 	"github.com/gofiber/fiber/v2"
 )
 
+// V1Prefix is the mount point of the current API version.
+const V1Prefix = "/api/v1"
+
+// RateLimitStore holds the rate limit buckets. Replace it with a shared Store before
+// calling Setups to enforce limits across replicas.
+var RateLimitStore ratelimit.Store = ratelimit.NewMemoryStore()
+
+// legacySunset is when the unversioned aliases stop being served, unless overridden by LEGACY_ROUTES_SUNSET.
+var legacySunset = time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)
+
+// Health registers the liveness and readiness probes. Call it before the request
+// middleware (auth, logging, ...) so probes are never subject to auth or rate limiting.
+func Health(app *fiber.App) {
+	app.Get("/healthz", controllers.Healthz)
+	app.Get("/readyz", controllers.Readyz)
+}
+
+// Metrics exposes the Prometheus scrape endpoint.
+func Metrics(app *fiber.App) {
+	app.Get("/metrics", metrics.Handler())
+}
+
 func Setups(app *fiber.App) {
 
-	app.Get("/suggestions", controllers.GetSuggestions)
-	app.Get("/suggestions/:id<int>", controllers.GetSuggestion)
+	V1(app.Group(V1Prefix))
+
+	// unversioned aliases kept for clients deployed before /api/v1 existed
+	sunset := legacySunset
+	if value := sql.GetEnvTime("LEGACY_ROUTES_SUNSET"); !value.IsZero() {
+		sunset = value
+	}
+	suggestionRoutes(app, middleware.Deprecated(V1Prefix, sunset))
+
+}
+
+// V1 registers the /api/v1 routes. A future V2 gets its own group and function,
+// reusing the controllers whose behaviour did not change.
+func V1(router fiber.Router) {
+
+	suggestionRoutes(router)
+
+	router.Patch("/suggestions/:id<int>/status", auth.RequireRole(auth.RoleAdmin, auth.RoleModerator), controllers.UpdateSuggestionStatus)
+	router.Post("/suggestions/:id<int>/comments", auth.Required(), controllers.CreateComment)
+	router.Post("/suggestions/:id<int>/comments/:comment<int>/replies", auth.Required(), controllers.CreateReply)
+	router.Put("/suggestions/:id<int>/subscription", auth.Required(), controllers.FollowSuggestion)
+	router.Delete("/suggestions/:id<int>/subscription", auth.Required(), controllers.UnfollowSuggestion)
+
+	me := router.Group("/me", auth.Required())
+	me.Get("/notifications", controllers.GetNotifications)
+	me.Post("/notifications/read", controllers.MarkAllNotificationsRead)
+	me.Post("/notifications/:id<int>/read", controllers.MarkNotificationRead)
+	me.Get("/preferences", controllers.GetPreferences)
+	me.Patch("/preferences", controllers.UpdatePreferences)
 
-	app.Put("/suggestions/:id<int>/vote", controllers.VoteSuggestion)
+	// linked from emails, authenticated by the token
+	router.Get("/unsubscribe", controllers.UnsubscribeStatus)
+	router.Post("/unsubscribe", controllers.Unsubscribe)
 
-	app.Post("/suggestions", controllers.CreateSuggestion)
-	app.Delete("/suggestions/:id", controllers.DeleteSuggestion)
+	router.Get("/events", controllers.StreamEvents)
+
+	router.Get("/ws/ticket", auth.Required(), controllers.SocketTicket)
+	router.Get("/ws/suggestions/:id<int>", controllers.RequireUpgrade, controllers.SuggestionSocket(RateLimitStore, voteUserPolicy()))
+
+	stats := router.Group("/analytics", auth.RequireRole(auth.RoleAdmin, auth.RoleAnalyst))
+	stats.Get("/summary", controllers.GetAnalyticsSummary)
+	stats.Get("/timeseries", controllers.GetAnalyticsTimeseries)
+
+	router.Get("/export/suggestions", auth.RequireRole(auth.RoleAdmin, auth.RoleAnalyst), controllers.ExportSuggestions)
+
+	router.Post("/import", auth.RequireRole(auth.RoleAdmin), controllers.ImportSuggestions)
+
+	reportLimit := ratelimit.Limit(RateLimitStore,
+		ratelimit.Policy{Name: "reports:create:ip", Limit: sql.GetEnvInt("RATE_LIMIT_REPORTS_PER_IP", 20), Window: time.Minute, Key: ratelimit.ByIP},
+		ratelimit.Policy{Name: "reports:create:user", Limit: sql.GetEnvInt("RATE_LIMIT_REPORTS_PER_USER", 10), Window: time.Minute, Key: ratelimit.ByUser},
+	)
+	router.Post("/reports", auth.Required(), reportLimit, controllers.CreateReport)
+
+	moderators := router.Group("/moderation", auth.RequireRole(auth.RoleAdmin, auth.RoleModerator))
+	moderators.Get("/queue", controllers.GetModerationQueue)
+	moderators.Post("/:type/:id<int>", controllers.ModerateContent)
+
+	admin := router.Group("/admin", auth.RequireRole(auth.RoleAdmin))
+	admin.Get("/webhooks", controllers.GetWebhooks)
+	admin.Post("/webhooks", controllers.CreateWebhook)
+	admin.Get("/webhooks/:id<int>", controllers.GetWebhook)
+	admin.Patch("/webhooks/:id<int>", controllers.UpdateWebhook)
+	admin.Delete("/webhooks/:id<int>", controllers.DeleteWebhook)
+	admin.Get("/webhooks/:id<int>/deliveries", controllers.GetWebhookDeliveries)
+	admin.Post("/webhooks/:id<int>/deliveries/:delivery<int>/redeliver", controllers.RedeliverWebhookDelivery)
+	admin.Get("/jobs", controllers.GetJobs)
+	admin.Get("/jobs/stats", controllers.GetJobStats)
+	admin.Get("/jobs/:id<int>", controllers.GetJob)
+	admin.Post("/jobs/:id<int>/retry", controllers.RetryJob)
+	admin.Get("/tasks", controllers.GetScheduledTasks)
+	admin.Post("/tasks/:name/run", controllers.RunScheduledTask)
+	admin.Get("/audit", controllers.GetAuditEvents)
+
+}
+
+func suggestionRoutes(router fiber.Router, handlers ...fiber.Handler) {
+	with := func(chain ...fiber.Handler) []fiber.Handler {
+		return append(append([]fiber.Handler{}, handlers...), chain...)
+	}
+
+	createLimit := ratelimit.Limit(RateLimitStore,
+		ratelimit.Policy{Name: "suggestions:create:ip", Limit: sql.GetEnvInt("RATE_LIMIT_CREATE_PER_IP", 10), Window: time.Minute, Key: ratelimit.ByIP},
+		ratelimit.Policy{Name: "suggestions:create:user", Limit: sql.GetEnvInt("RATE_LIMIT_CREATE_PER_USER", 5), Window: time.Minute, Key: ratelimit.ByUser},
+	)
+	voteLimit := ratelimit.Limit(RateLimitStore,
+		ratelimit.Policy{Name: "suggestions:vote:ip", Limit: sql.GetEnvInt("RATE_LIMIT_VOTE_PER_IP", 60), Window: time.Minute, Key: ratelimit.ByIP},
+		voteUserPolicy(),
+	)
+
+	router.Get("/suggestions", with(controllers.GetSuggestions)...)
+	router.Get("/suggestions/:id<int>", with(controllers.GetSuggestion)...)
+
+	router.Put("/suggestions/:id<int>/vote", with(idempotency.Middleware(), voteLimit, controllers.VoteSuggestion)...)
+
+	router.Post("/suggestions", with(idempotency.Middleware(), createLimit, controllers.CreateSuggestion)...)
+	router.Delete("/suggestions/:id", with(auth.RequireRole(auth.RoleAdmin, auth.RoleModerator), controllers.DeleteSuggestion)...)
+}
 
+// voteUserPolicy is the per-user vote limit, shared by the HTTP endpoint and the suggestion socket.
+func voteUserPolicy() ratelimit.Policy {
+	return ratelimit.Policy{Name: "suggestions:vote:user", Limit: sql.GetEnvInt("RATE_LIMIT_VOTE_PER_USER", 30), Window: time.Minute, Key: ratelimit.ByUser}
 }
@@ -0,0 +1,89 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"1-x * * * *",
+		"a * * * *",
+		"@often",
+		"@every 500ms",
+		"@every soon",
+	}
+	for _, spec := range tests {
+		t.Run(spec, func(t *testing.T) {
+			if _, err := Parse(spec); err == nil {
+				t.Errorf("Parse(%q) succeeded, want an error", spec)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	at := func(year int, month time.Month, day, hour, minute, second int) time.Time {
+		return time.Date(year, month, day, hour, minute, second, 0, time.UTC)
+	}
+	// a Wednesday
+	wednesday := at(2025, time.January, 15, 10, 30, 0)
+
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"every minute", "* * * * *", wednesday, at(2025, time.January, 15, 10, 31, 0)},
+		{"seconds are dropped", "* * * * *", at(2025, time.January, 15, 10, 30, 59), at(2025, time.January, 15, 10, 31, 0)},
+		{"step", "*/15 * * * *", wednesday, at(2025, time.January, 15, 10, 45, 0)},
+		{"step from a value", "5/15 * * * *", wednesday, at(2025, time.January, 15, 10, 35, 0)},
+		{"lists", "0,30 8,18 * * *", wednesday, at(2025, time.January, 15, 18, 0, 0)},
+		{"hourly", "@hourly", wednesday, at(2025, time.January, 15, 11, 0, 0)},
+		{"daily", "@daily", wednesday, at(2025, time.January, 16, 0, 0, 0)},
+		{"weekdays", "30 9 * * 1-5", wednesday, at(2025, time.January, 16, 9, 30, 0)},
+		{"weekly", "@weekly", wednesday, at(2025, time.January, 19, 0, 0, 0)},
+		{"7 is Sunday", "0 0 * * 7", wednesday, at(2025, time.January, 19, 0, 0, 0)},
+		{"monthly", "@monthly", wednesday, at(2025, time.February, 1, 0, 0, 0)},
+		{"month step", "0 0 1 */3 *", wednesday, at(2025, time.April, 1, 0, 0, 0)},
+		{"month only", "0 12 * 3 *", wednesday, at(2025, time.March, 1, 12, 0, 0)},
+		{"yearly", "@yearly", wednesday, at(2026, time.January, 1, 0, 0, 0)},
+		{"year rollover", "0 0 1 1 *", at(2025, time.December, 31, 23, 59, 0), at(2026, time.January, 1, 0, 0, 0)},
+		{"leap day", "0 0 29 2 *", wednesday, at(2028, time.February, 29, 0, 0, 0)},
+
+		// day of month and day of week: either matches when both are restricted
+		{"day of month only", "0 0 13 * *", wednesday, at(2025, time.February, 13, 0, 0, 0)},
+		{"day of week only", "0 0 * * 5", wednesday, at(2025, time.January, 17, 0, 0, 0)},
+		{"both, day of week first", "0 0 13 * 5", wednesday, at(2025, time.January, 17, 0, 0, 0)},
+		{"both, day of month first", "0 0 13 * 5", at(2025, time.February, 10, 0, 0, 0), at(2025, time.February, 13, 0, 0, 0)},
+
+		{"never, 30 February", "0 0 30 2 *", wednesday, time.Time{}},
+		{"never, 31 April", "0 0 31 4 *", wednesday, time.Time{}},
+
+		{"interval", "@every 90s", wednesday, at(2025, time.January, 15, 10, 31, 30)},
+		{"interval keeps seconds", "@every 1h", at(2025, time.January, 15, 10, 30, 15), at(2025, time.January, 15, 11, 30, 15)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.spec, err)
+			}
+			if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) of %q = %s, want %s", tt.from, tt.spec, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"feedback-io.backend/audit"
+	"feedback-io.backend/config"
+	"feedback-io.backend/metrics"
+	"feedback-io.backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// defaultTimeout bounds a run of a task without its own Timeout.
+	defaultTimeout = 10 * time.Minute
+	errorLimit     = 1024
+)
+
+// Task is periodic work. Every replica registers the same tasks; each run is claimed
+// by one of them.
+type Task struct {
+	// Name identifies the task in the database and the admin API, e.g. "send-digests".
+	Name string
+	// Schedule is the default cron expression (see Parse). SCHEDULE_<NAME> overrides it,
+	// e.g. SCHEDULE_SEND_DIGESTS; "off" disables the task.
+	Schedule string
+	// Timeout bounds one run; the run is cancelled after it.
+	Timeout time.Duration
+	Run     func(ctx context.Context) error
+}
+
+type entry struct {
+	task     Task
+	spec     string
+	schedule Schedule
+}
+
+// Scheduler runs tasks when they are due. The schedule state lives in the
+// scheduled_tasks table: a replica becomes the leader of a run by claiming its row with a
+// conditional update, which holds a lease until the run ends or times out. A replica
+// that dies mid-run loses the lease and another one runs the task again.
+type Scheduler struct {
+	db       *gorm.DB
+	name     string
+	interval time.Duration
+	entries  []entry
+
+	wake    chan struct{}
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	running sync.WaitGroup
+}
+
+var scheduler *Scheduler
+
+// Start registers tasks and runs them on their schedule. Call it after the database is
+// connected.
+func Start(tasks ...Task) {
+	hostname, _ := os.Hostname()
+	s := &Scheduler{
+		db:       config.DB,
+		name:     hostname + ":" + strconv.Itoa(os.Getpid()),
+		interval: config.GetEnvDuration("SCHEDULER_INTERVAL", 15*time.Second),
+		wake:     make(chan struct{}, 1),
+	}
+
+	for _, task := range tasks {
+		spec := task.Schedule
+		if value := os.Getenv(envKey(task.Name)); value != "" {
+			spec = value
+		}
+		if spec == "off" {
+			continue
+		}
+		schedule, err := Parse(spec)
+		if err != nil {
+			log.Fatalf("Error parsing schedule of task %s: %v", task.Name, err)
+		}
+		if task.Timeout <= 0 {
+			task.Timeout = defaultTimeout
+		}
+		s.entries = append(s.entries, entry{task: task, spec: spec, schedule: schedule})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	scheduler = s
+	s.wg.Add(1)
+	go s.loop(ctx)
+}
+
+// Stop stops the scheduler and cancels runs in progress. An interrupted run stays due,
+// so another replica picks it up.
+func Stop() {
+	if scheduler == nil {
+		return
+	}
+	scheduler.cancel()
+	scheduler.wg.Wait()
+	scheduler.running.Wait()
+}
+
+// Wake makes the scheduler look for due tasks now instead of at the next tick.
+func Wake() {
+	if scheduler == nil {
+		return
+	}
+	select {
+	case scheduler.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Tasks returns the names of the enabled tasks.
+func Tasks() []string {
+	if scheduler == nil {
+		return nil
+	}
+	return scheduler.names()
+}
+
+func (s *Scheduler) names() []string {
+	names := make([]string, 0, len(s.entries))
+	for _, e := range s.entries {
+		names = append(names, e.task.Name)
+	}
+	return names
+}
+
+// ErrRunning is returned by RunNow when the task is running.
+var ErrRunning = errors.New("task is running")
+
+// RunNow makes a task due immediately and records it in the audit log. It returns
+// gorm.ErrRecordNotFound for unknown tasks and ErrRunning when the task is running.
+func RunNow(db *gorm.DB, actor audit.Actor, name string) (models.ScheduledTask, error) {
+	var task models.ScheduledTask
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("name = ?", name).First(&task).Error; err != nil {
+			return err
+		}
+
+		now := models.DateTime{Time: time.Now()}
+		result := tx.Model(&models.ScheduledTask{}).
+			Where("name = ? AND (locked_until IS NULL OR locked_until <= ?)", name, now).
+			Update("next_run_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRunning
+		}
+		if err := tx.Where("name = ?", name).First(&task).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, actor, audit.Event{
+			Action:     audit.TaskRun,
+			TargetType: audit.TargetTask,
+			TargetId:   task.Name,
+			After:      map[string]any{"next_run_at": task.NextRunAt},
+		})
+	})
+	if err != nil {
+		return task, err
+	}
+	Wake()
+	return task, nil
+}
+
+func envKey(name string) string {
+	return "SCHEDULE_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// sync creates the rows of new tasks and reschedules tasks whose expression changed.
+func (s *Scheduler) sync() error {
+	now := time.Now()
+	for _, e := range s.entries {
+		var row models.ScheduledTask
+		err := s.db.Where("name = ?", e.task.Name).First(&row).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			row = models.ScheduledTask{
+				Name:      e.task.Name,
+				Schedule:  e.spec,
+				Status:    models.TaskIdle,
+				NextRunAt: models.DateTime{Time: e.schedule.Next(now)},
+			}
+			// another replica may be starting too
+			if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&row).Error; err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case row.Schedule != e.spec:
+			if err := s.db.Model(&row).Updates(map[string]any{
+				"schedule":    e.spec,
+				"next_run_at": models.DateTime{Time: e.schedule.Next(now)},
+			}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// loop registers the tasks, retrying every interval while that fails (e.g. before
+// `migrate` created scheduled_tasks), then runs them when due.
+func (s *Scheduler) loop(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	synced := false
+	for {
+		if !synced {
+			if err := s.sync(); err != nil {
+				slog.Error("failed to register scheduled tasks", "error", err)
+			} else {
+				synced = true
+			}
+		}
+		if synced {
+			s.runDue(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// runDue claims every due task that no replica holds and runs it in the background.
+func (s *Scheduler) runDue(ctx context.Context) {
+	now := models.DateTime{Time: time.Now()}
+
+	var due []string
+	if err := s.db.Model(&models.ScheduledTask{}).
+		Where("name IN ? AND next_run_at <= ? AND (locked_until IS NULL OR locked_until <= ?)", s.names(), now, now).
+		Pluck("name", &due).Error; err != nil {
+		slog.Error("failed to load due tasks", "error", err)
+		return
+	}
+
+	for _, e := range s.entries {
+		if ctx.Err() != nil {
+			return
+		}
+		if !slices.Contains(due, e.task.Name) || !s.claim(e, now) {
+			continue
+		}
+		s.running.Add(1)
+		go s.execute(ctx, e)
+	}
+}
+
+func (s *Scheduler) claim(e entry, now models.DateTime) bool {
+	until := models.DateTime{Time: now.Add(e.task.Timeout + time.Minute)}
+	claim := s.db.Model(&models.ScheduledTask{}).
+		Where("name = ? AND next_run_at <= ? AND (locked_until IS NULL OR locked_until <= ?)", e.task.Name, now, now).
+		Updates(map[string]any{
+			"status":       models.TaskRunning,
+			"locked_by":    s.name,
+			"locked_until": until,
+			"last_run_at":  now,
+		})
+	if claim.Error != nil {
+		slog.Error("failed to claim task", "task", e.task.Name, "error", claim.Error)
+	}
+	return claim.Error == nil && claim.RowsAffected == 1
+}
+
+// execute runs a claimed task and records the outcome and the next run.
+func (s *Scheduler) execute(ctx context.Context, e entry) {
+	defer s.running.Done()
+	log := slog.With("task", e.task.Name)
+
+	start := time.Now()
+	runCtx, cancel := context.WithTimeout(ctx, e.task.Timeout)
+	err := call(runCtx, e.task)
+	cancel()
+	duration := time.Since(start)
+
+	updates := map[string]any{
+		"status":           models.TaskSucceeded,
+		"last_error":       "",
+		"last_duration_ms": duration.Milliseconds(),
+		"locked_by":        "",
+		"locked_until":     nil,
+	}
+	switch {
+	case err != nil && ctx.Err() != nil:
+		// shutting down: leave the run due for another replica
+		updates["status"] = models.TaskFailed
+		updates["last_error"] = "interrupted by shutdown"
+		log.Warn("task interrupted by shutdown")
+	case err != nil:
+		updates["status"] = models.TaskFailed
+		updates["last_error"] = models.Truncate(err.Error(), errorLimit)
+		updates["next_run_at"] = models.DateTime{Time: e.schedule.Next(time.Now())}
+		metrics.TaskRun(e.task.Name, models.TaskFailed)
+		log.Error("task failed", "duration", duration, "error", err)
+	default:
+		updates["next_run_at"] = models.DateTime{Time: e.schedule.Next(time.Now())}
+		metrics.TaskRun(e.task.Name, models.TaskSucceeded)
+		log.Info("task finished", "duration", duration)
+	}
+
+	if err := s.db.Model(&models.ScheduledTask{}).
+		Where("name = ? AND locked_by = ?", e.task.Name, s.name).
+		Updates(updates).Error; err != nil {
+		log.Error("failed to record task run", "error", err)
+	}
+}
+
+// call runs the task, turning a panic into an error.
+func call(ctx context.Context, task Task) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return task.Run(ctx)
+}
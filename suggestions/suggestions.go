@@ -0,0 +1,79 @@
+// Package suggestions holds the privileged actions on suggestions: status changes and
+// deletions. Like the other audited actions, each writes the change and its audit event in
+// one transaction.
+package suggestions
+
+import (
+	"context"
+	"time"
+
+	"feedback-io.backend/audit"
+	"feedback-io.backend/cache"
+	"feedback-io.backend/models"
+	"gorm.io/gorm"
+)
+
+// SetStatus changes the status of suggestion, which is updated in place, and returns the
+// previous one. Setting the current status changes nothing and records no event.
+func SetStatus(ctx context.Context, db *gorm.DB, actor audit.Actor, suggestion *models.Suggestion, status string) (string, error) {
+	previous := suggestion.Status
+	if previous == status {
+		return previous, nil
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(suggestion).Update("status", status).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, actor, audit.Event{
+			Action:     audit.SuggestionStatusChange,
+			TargetType: audit.TargetSuggestion,
+			TargetId:   suggestion.Id,
+			Before:     map[string]any{"status": previous},
+			After:      map[string]any{"status": status},
+		})
+	})
+	if err != nil {
+		return previous, err
+	}
+	cache.Invalidate(ctx, cache.SuggestionsPrefix)
+	return previous, nil
+}
+
+// Delete soft deletes a suggestion with its comments and replies, and returns it as it
+// was. A missing or already deleted suggestion returns gorm.ErrRecordNotFound.
+func Delete(ctx context.Context, db *gorm.DB, actor audit.Actor, id uint) (models.Suggestion, error) {
+	var suggestion models.Suggestion
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&suggestion, id).Error; err != nil {
+			return err
+		}
+		if err := SoftDelete(tx, id, models.DateTime{Time: time.Now()}); err != nil {
+			return err
+		}
+		return audit.Record(tx, actor, audit.Event{
+			Action:     audit.SuggestionDelete,
+			TargetType: audit.TargetSuggestion,
+			TargetId:   suggestion.Id,
+			Before:     suggestion,
+		})
+	})
+	if err != nil {
+		return suggestion, err
+	}
+	cache.Invalidate(ctx, cache.SuggestionsPrefix)
+	return suggestion, nil
+}
+
+// SoftDelete marks a suggestion and the comments and replies below it deleted at now. It
+// records no audit event: callers do, in the same transaction tx.
+func SoftDelete(tx *gorm.DB, id uint, now models.DateTime) error {
+	comments := tx.Model(&models.Comment{}).Select("id").Where("suggestion_id = ?", id)
+	if err := tx.Model(&models.Reply{}).Where("comment_id IN (?)", comments).Update("deleted_at", now).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Comment{}).Where("suggestion_id = ?", id).Update("deleted_at", now).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Suggestion{}).Where("id = ?", id).Update("deleted_at", now).Error
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"feedback-io.backend/auth"
+)
+
+// runToken implements `token -user ID [-roles admin,...] [-ttl DURATION]`, which prints an access token
+// signed with AUTH_TOKEN_SECRET, e.g. for scripts and operators. It returns the exit
+// status: 2 on usage errors.
+func runToken(args []string) int {
+	flags := flag.NewFlagSet("token", flag.ContinueOnError)
+	user := flags.Uint("user", 0, "id of the user the token identifies")
+	roles := flags.String("roles", "", "comma separated roles, e.g. admin or moderator")
+	ttl := flags.Duration("ttl", 24*time.Hour, "validity of the token")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: token -user ID [-roles admin,...] [-ttl DURATION]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *user == 0 || *ttl <= 0 || flags.NArg() != 0 {
+		flags.Usage()
+		return 2
+	}
+
+	fmt.Println(auth.IssueToken(auth.Claims{UserId: *user, Roles: strings.Split(*roles, ",")}, *ttl))
+	return 0
+}
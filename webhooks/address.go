@@ -0,0 +1,116 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+
+	"feedback-io.backend/config"
+)
+
+// ErrNonPublicAddress is returned for webhook URLs pointing at loopback, private, link-local
+// or otherwise internal addresses, which would let admins probe the internal network
+// and read the answers in the delivery log.
+var ErrNonPublicAddress = errors.New("webhook URL does not resolve to a public address")
+
+// reserved are ranges not covered by the netip predicates that must not be reached either.
+var reserved = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"),  // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),    // reserved, and broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64, may embed an internal IPv4
+	netip.MustParsePrefix("64:ff9b:1::/48"), // local-use NAT64
+	netip.MustParsePrefix("2002::/16"),      // 6to4, may embed an internal IPv4
+	netip.MustParsePrefix("2001::/32"),      // Teredo
+	netip.MustParsePrefix("fec0::/10"),      // deprecated site-local
+}
+
+// allowPrivate reads WEBHOOK_ALLOW_PRIVATE_NETWORKS, which lifts the address checks for
+// local development, e.g. against an httptest server.
+func allowPrivate() bool {
+	return config.GetEnvBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false)
+}
+
+// publicAddress reports whether addr may receive webhooks.
+func publicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range reserved {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckURL resolves the host of rawURL and returns ErrNonPublicAddress unless every
+// address it resolves to is public. The dispatcher checks again when connecting, since
+// DNS answers can change between the two.
+func CheckURL(ctx context.Context, rawURL string) error {
+	if allowPrivate() {
+		return nil
+	}
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", target.Hostname())
+	if err != nil {
+		return fmt.Errorf("resolving webhook host: %w", err)
+	}
+	for _, addr := range addrs {
+		if !publicAddress(addr) {
+			return ErrNonPublicAddress
+		}
+	}
+	return nil
+}
+
+// dialAllowed decides which addresses dialControl lets through; tests replace it to reach
+// a local server.
+var dialAllowed = publicAddress
+
+// dialControl refuses connections to non-public addresses. It runs after name resolution,
+// on the address actually dialed, so a host rebinding to an internal address is caught.
+func dialControl(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !dialAllowed(addrPort.Addr()) {
+		return ErrNonPublicAddress
+	}
+	return nil
+}
+
+// newClient returns the HTTP client of the dispatcher. Unless private networks are allowed,
+// it only connects to public addresses, also when following redirects, and ignores
+// proxy settings from the environment.
+func newClient(timeout time.Duration) *http.Client {
+	if allowPrivate() {
+		return &http.Client{Timeout: timeout}
+	}
+	dialer := &net.Dialer{Timeout: timeout, Control: dialControl}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConnsPerHost: 4,
+		},
+	}
+}
@@ -0,0 +1,147 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestPublicAddress(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.215.14", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},
+		{"127.1.2.3", false},
+		{"10.0.0.1", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"0.0.0.0", false},
+		{"100.64.0.1", false},
+		{"198.18.0.1", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::", false},
+		{"::1", false},
+		{"fe80::1", false},
+		{"fc00::1", false},
+		{"fec0::1", false},
+		{"ff02::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"::ffff:93.184.215.14", true},
+		{"64:ff9b::a00:1", false},
+		{"2002:a00:1::", false},
+		{"2001:0:a00:1::", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := publicAddress(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("publicAddress(%s) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	// IP literals resolve without DNS
+	tests := []struct {
+		name    string
+		url     string
+		allow   bool
+		wantErr error
+	}{
+		{"public", "https://93.184.215.14/hook", false, nil},
+		{"loopback", "http://127.0.0.1:8080/hook", false, ErrNonPublicAddress},
+		{"metadata service", "http://169.254.169.254/latest/meta-data", false, ErrNonPublicAddress},
+		{"IPv6 loopback", "http://[::1]/hook", false, ErrNonPublicAddress},
+		{"IPv4-mapped", "http://[::ffff:10.0.0.1]/hook", false, ErrNonPublicAddress},
+		{"allowed for development", "http://127.0.0.1:8080/hook", true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.allow {
+				t.Setenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS", "true")
+			}
+			if err := CheckURL(context.Background(), tt.url); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckURL(%q) = %v, want %v", tt.url, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDialControl(t *testing.T) {
+	tests := []struct {
+		address string
+		wantErr error
+	}{
+		{"93.184.215.14:443", nil},
+		{"127.0.0.1:80", ErrNonPublicAddress},
+		{"[::ffff:127.0.0.1]:80", ErrNonPublicAddress},
+		{"[fe80::1%eth0]:80", ErrNonPublicAddress},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			if err := dialControl("tcp", tt.address, nil); !errors.Is(err, tt.wantErr) {
+				t.Errorf("dialControl(%q) = %v, want %v", tt.address, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClientRefusesPrivateAddresses(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("secret"))
+	}))
+	defer internal.Close()
+
+	t.Run("direct", func(t *testing.T) {
+		resp, err := newClient(time.Second).Get(internal.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if !errors.Is(err, ErrNonPublicAddress) {
+			t.Errorf("Get = %v, want %v", err, ErrNonPublicAddress)
+		}
+	})
+
+	t.Run("after a redirect", func(t *testing.T) {
+		// the redirecting server stands in for a public one on another loopback address
+		listener, err := net.Listen("tcp", "127.0.0.2:0")
+		if err != nil {
+			t.Skipf("cannot listen on 127.0.0.2: %v", err)
+		}
+		redirected := false
+		redirect := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			redirected = true
+			http.Redirect(w, r, internal.URL, http.StatusFound)
+		}))
+		redirect.Listener.Close()
+		redirect.Listener = listener
+		redirect.Start()
+		defer redirect.Close()
+
+		public := netip.MustParseAddr("127.0.0.2")
+		dialAllowed = func(addr netip.Addr) bool { return addr == public }
+		defer func() { dialAllowed = publicAddress }()
+
+		resp, err := newClient(time.Second).Get(redirect.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if !redirected {
+			t.Fatal("the redirecting server was not reached")
+		}
+		if !errors.Is(err, ErrNonPublicAddress) {
+			t.Errorf("Get = %v, want %v", err, ErrNonPublicAddress)
+		}
+	})
+}
@@ -0,0 +1,134 @@
+package webhooks
+
+import (
+	"time"
+
+	"feedback-io.backend/audit"
+	"feedback-io.backend/jobs"
+	"feedback-io.backend/models"
+	"gorm.io/gorm"
+)
+
+// The admin actions on webhooks. Each writes the change and its audit event in one
+// transaction; secrets are never part of the event.
+
+// Changes are the fields of a webhook to update; nil fields are kept. RotateSecret
+// replaces the signing secret.
+type Changes struct {
+	Url          *string
+	Events       *[]string
+	Description  *string
+	Active       *bool
+	RotateSecret bool
+}
+
+// Create stores hook, which gets its id.
+func Create(db *gorm.DB, actor audit.Actor, hook *models.Webhook) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(hook).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, actor, audit.Event{
+			Action:     audit.WebhookCreate,
+			TargetType: audit.TargetWebhook,
+			TargetId:   hook.Id,
+			After:      hook,
+		})
+	})
+}
+
+// Update applies changes to hook, which is updated in place. Validate the resulting URL
+// and events first.
+func Update(db *gorm.DB, actor audit.Actor, hook *models.Webhook, changes Changes) error {
+	before := *hook
+	updates := map[string]any{}
+	if changes.Url != nil {
+		hook.Url = *changes.Url
+		updates["url"] = hook.Url
+	}
+	if changes.Events != nil {
+		hook.Events = *changes.Events
+		updates["events"] = hook.Events
+	}
+	if changes.Description != nil {
+		hook.Description = *changes.Description
+		updates["description"] = hook.Description
+	}
+	if changes.Active != nil {
+		hook.Active = *changes.Active
+		updates["active"] = hook.Active
+	}
+	if changes.RotateSecret {
+		hook.Secret = NewSecret()
+		updates["secret"] = hook.Secret
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(hook).Updates(updates).Error; err != nil {
+			return err
+		}
+		// the secret itself is never logged, only that it was rotated
+		return audit.Record(tx, actor, audit.Event{
+			Action:     audit.WebhookUpdate,
+			TargetType: audit.TargetWebhook,
+			TargetId:   hook.Id,
+			Before:     before,
+			After:      map[string]any{"webhook": hook, "secret_rotated": changes.RotateSecret},
+		})
+	})
+}
+
+// Delete removes hook with its delivery log.
+func Delete(db *gorm.DB, actor audit.Actor, hook models.Webhook) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", hook.Id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&hook).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, actor, audit.Event{
+			Action:     audit.WebhookDelete,
+			TargetType: audit.TargetWebhook,
+			TargetId:   hook.Id,
+			Before:     hook,
+		})
+	})
+}
+
+// Redeliver queues a new delivery with the payload of original. The copy is sent with a
+// fresh timestamp, signature and delivery id; receivers deduplicate on the event uuid in
+// the payload.
+func Redeliver(db *gorm.DB, actor audit.Actor, original models.WebhookDelivery) (models.WebhookDelivery, error) {
+	delivery := models.WebhookDelivery{
+		WebhookId:     original.WebhookId,
+		EventType:     original.EventType,
+		Payload:       original.Payload,
+		Status:        models.DeliveryPending,
+		RedeliveryOf:  original.Id,
+		NextAttemptAt: models.DateTime{Time: time.Now()},
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&delivery).Error; err != nil {
+			return err
+		}
+		if _, err := jobs.Enqueue(tx, JobDeliver, deliverPayload{DeliveryId: delivery.Id}); err != nil {
+			return err
+		}
+		return audit.Record(tx, actor, audit.Event{
+			Action:     audit.WebhookRedeliver,
+			TargetType: audit.TargetWebhook,
+			TargetId:   original.WebhookId,
+			After:      map[string]any{"delivery_id": original.Id, "redelivery_id": delivery.Id},
+		})
+	})
+	if err != nil {
+		return delivery, err
+	}
+	// the job's wake up came before the commit
+	jobs.Wake()
+	return delivery, nil
+}
@@ -0,0 +1,305 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	mathrand "math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"feedback-io.backend/config"
+	"feedback-io.backend/events"
+	"feedback-io.backend/jobs"
+	"feedback-io.backend/metrics"
+	"feedback-io.backend/models"
+	"gorm.io/gorm"
+)
+
+// JobDeliver is the job kind that makes one attempt of a delivery.
+const JobDeliver = "webhook.deliver"
+
+const (
+	// maxBackoff caps the delay between two attempts.
+	maxBackoff = 6 * time.Hour
+	// responseLimit is how much of the receiver's response is kept in the delivery log.
+	responseLimit = 4 << 10
+	errorLimit    = 1024
+)
+
+func init() {
+	jobs.Register(JobDeliver, deliver)
+}
+
+// deliverPayload is the payload of a JobDeliver job.
+type deliverPayload struct {
+	DeliveryId uint `json:"delivery_id"`
+}
+
+// Dispatcher turns published events into deliveries for the subscribed webhooks. Every
+// attempt is a JobDeliver job, so deliveries share the jobs queue and its workers, and a
+// failed attempt queues the next one with exponential backoff. The deliveries themselves
+// are the delivery log.
+type Dispatcher struct {
+	db          *gorm.DB
+	client      *http.Client
+	maxAttempts int
+	backoff     time.Duration
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var dispatcher *Dispatcher
+
+// Start begins queueing deliveries with settings from the environment. Call it after
+// the database is connected and before jobs.Start, which runs the attempts.
+func Start() {
+	d := &Dispatcher{
+		db:          config.DB,
+		client:      newClient(config.GetEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second)),
+		maxAttempts: config.GetEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		backoff:     config.GetEnvDuration("WEBHOOK_RETRY_BACKOFF", 30*time.Second),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	// subscribe before returning so no event published after Start is missed
+	_, sub := events.Default.Subscribe(events.Filter{}, 0)
+	d.wg.Add(1)
+	go d.listen(ctx, sub)
+
+	dispatcher = d
+}
+
+// Stop stops queueing deliveries. Attempts already queued are left to the jobs pool.
+func Stop() {
+	if dispatcher == nil {
+		return
+	}
+	dispatcher.cancel()
+	dispatcher.wg.Wait()
+}
+
+// NewSecret returns a random signing secret for a new webhook.
+func NewSecret() string {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return "whsec_" + hex.EncodeToString(buf)
+}
+
+// listen queues a delivery per subscribed webhook for every published event.
+func (d *Dispatcher) listen(ctx context.Context, sub *events.Subscription) {
+	defer d.wg.Done()
+
+	var lastID uint64
+	for {
+	receive:
+		for {
+			select {
+			case <-ctx.Done():
+				sub.Close()
+				return
+			case event, ok := <-sub.C:
+				if !ok {
+					break receive
+				}
+				d.enqueue(event)
+				lastID = event.ID
+			}
+		}
+
+		// dropped for lagging behind: resume from the event log
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+
+		var backlog events.Backlog
+		backlog, sub = events.Default.Subscribe(events.Filter{}, lastID)
+		if backlog.Gap {
+			slog.Warn("webhook events were evicted before they could be queued", "after_event_id", lastID)
+		}
+		for _, event := range backlog.Events {
+			d.enqueue(event)
+			lastID = event.ID
+		}
+	}
+}
+
+func (d *Dispatcher) enqueue(event events.Event) {
+	var hooks []models.Webhook
+	if err := d.db.Where("active = ?", true).Find(&hooks).Error; err != nil {
+		slog.Error("failed to load webhooks", "event_id", event.ID, "error", err)
+		return
+	}
+
+	var deliveries []models.WebhookDelivery
+	for _, hook := range hooks {
+		if !hook.Subscribed(event.Type) {
+			continue
+		}
+		payload, err := json.Marshal(event)
+		if err != nil {
+			slog.Error("failed to encode webhook payload", "event_id", event.ID, "error", err)
+			return
+		}
+		deliveries = append(deliveries, models.WebhookDelivery{
+			WebhookId:     hook.Id,
+			EventType:     event.Type,
+			Payload:       payload,
+			Status:        models.DeliveryPending,
+			NextAttemptAt: models.DateTime{Time: time.Now()},
+		})
+	}
+	if len(deliveries) == 0 {
+		return
+	}
+
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&deliveries).Error; err != nil {
+			return err
+		}
+		for _, delivery := range deliveries {
+			if _, err := jobs.Enqueue(tx, JobDeliver, deliverPayload{DeliveryId: delivery.Id}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("failed to queue webhook deliveries", "event_id", event.ID, "error", err)
+	}
+}
+
+// deliver runs a JobDeliver job.
+func deliver(ctx context.Context, payload json.RawMessage) error {
+	var job deliverPayload
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return jobs.Permanent(err)
+	}
+	if dispatcher == nil {
+		return errors.New("webhook dispatcher is not started")
+	}
+	return dispatcher.attempt(ctx, job.DeliveryId)
+}
+
+// attempt sends a pending delivery once and records the outcome, queueing the next
+// attempt after a failure. An error means the outcome couldn't be recorded, and the job
+// is retried.
+func (d *Dispatcher) attempt(ctx context.Context, deliveryID uint) error {
+	db := d.db.WithContext(ctx)
+
+	var delivery models.WebhookDelivery
+	if err := db.Preload("Webhook").First(&delivery, deliveryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil // deleted with its webhook
+		}
+		return err
+	}
+	if delivery.Status != models.DeliveryPending {
+		return nil
+	}
+
+	log := slog.With(
+		"webhook_id", delivery.WebhookId, "delivery_id", delivery.Id, "event_type", delivery.EventType)
+
+	attempts := delivery.Attempts + 1
+	updates := map[string]any{"attempts": attempts}
+	if delivery.Webhook == nil || !delivery.Webhook.Active {
+		updates["status"] = models.DeliveryFailed
+		updates["error"] = "Webhook is inactive"
+		return d.record(db, delivery, updates, time.Time{})
+	}
+
+	status, body, err := d.send(ctx, delivery)
+	updates["response_status"] = status
+	updates["response_body"] = body
+	updates["error"] = ""
+
+	var retryAt time.Time
+	switch {
+	case err == nil && status >= 200 && status < 300:
+		updates["status"] = models.DeliverySucceeded
+		updates["delivered_at"] = models.DateTime{Time: time.Now()}
+		metrics.WebhookAttempt(models.DeliverySucceeded)
+	default:
+		if err != nil {
+			updates["error"] = models.Truncate(err.Error(), errorLimit)
+		} else {
+			updates["error"] = "Unexpected response status " + strconv.Itoa(status)
+		}
+		if attempts >= d.maxAttempts {
+			updates["status"] = models.DeliveryFailed
+			metrics.WebhookAttempt(models.DeliveryFailed)
+			log.Warn("webhook delivery failed permanently", "attempts", attempts, "status", status, "error", updates["error"])
+		} else {
+			retryAt = time.Now().Add(d.delay(attempts))
+			updates["next_attempt_at"] = models.DateTime{Time: retryAt}
+			metrics.WebhookAttempt("retrying")
+		}
+	}
+	return d.record(db, delivery, updates, retryAt)
+}
+
+// record saves the outcome of an attempt and, unless retryAt is zero, queues the next one
+// in the same transaction. The update is conditional on the attempt count, so a job run
+// twice (after its lease expired) records and retries only once.
+func (d *Dispatcher) record(db *gorm.DB, delivery models.WebhookDelivery, updates map[string]any, retryAt time.Time) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.WebhookDelivery{}).
+			Where("id = ? AND status = ? AND attempts = ?", delivery.Id, models.DeliveryPending, delivery.Attempts).
+			Updates(updates)
+		if result.Error != nil || result.RowsAffected == 0 || retryAt.IsZero() {
+			return result.Error
+		}
+		_, err := jobs.EnqueueAt(tx, JobDeliver, deliverPayload{DeliveryId: delivery.Id}, retryAt)
+		return err
+	})
+}
+
+// send posts the payload and returns the response status and the start of its body.
+func (d *Dispatcher) send(ctx context.Context, delivery models.WebhookDelivery) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Webhook.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "feedback-io-webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(uint64(delivery.Id), 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, responseLimit))
+	return resp.StatusCode, models.Truncate(string(body), responseLimit), nil
+}
+
+// delay is the wait before the next attempt: the base backoff doubled per failed
+// attempt, capped at maxBackoff, with up to 10% jitter so receivers aren't hit in bursts.
+func (d *Dispatcher) delay(attempts int) time.Duration {
+	delay := d.backoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxBackoff)
+	return delay + time.Duration(mathrand.Int64N(int64(delay/10)+1))
+}